@@ -0,0 +1,95 @@
+package pomo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Extend is the default step used by extend, shorten and the TUI keys.
+	Extend = "5m"
+	// MaxExtensions is the default number of times a session can be
+	// extended, 0 in the config means no limit.
+	MaxExtensions = 3
+)
+
+// Adjust changes the duration of the running session by d and records the
+// adjustment so the planned duration can still be told apart.
+func (s *Session) Adjust(d time.Duration) error {
+	if s.ID == uuid.Nil || !s.isRunning() {
+		return fmt.Errorf("no session is running")
+	}
+
+	if d > 0 {
+		max := conf.QueryInt("max_extensions", MaxExtensions)
+		if max > 0 && s.Extensions() >= max {
+			return fmt.Errorf("session was already extended %d times", max)
+		}
+	}
+
+	if s.Duration+d <= 0 {
+		return fmt.Errorf("cannot shorten a %s session by %s", s.Duration, -d)
+	}
+
+	s.Duration += d
+	s.Adjustments = append(s.Adjustments, d)
+	if !s.Until.IsZero() {
+		s.Until = s.Until.Add(d)
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	publish(EventAdjust, *s)
+
+	return nil
+}
+
+// Planned returns the duration the session was started with.
+func (s *Session) Planned() time.Duration {
+	planned := s.Duration
+	for _, adj := range s.Adjustments {
+		planned -= adj
+	}
+	for _, snooze := range s.Snoozes {
+		planned -= snooze
+	}
+	return planned
+}
+
+// splitTime splits the time the session ran into the time it was planned
+// with and the time it ran in its extensions and snoozes. The overrun past
+// its end is in neither.
+func (s *Session) splitTime() (time.Duration, time.Duration) {
+	ran := sessionEnd(*s).Sub(s.StartTime)
+	planned := min(ran, s.Planned())
+	return planned, max(0, min(ran, s.Duration)-planned)
+}
+
+// Extensions returns how many times the session was extended, snoozes
+// excluded.
+func (s *Session) Extensions() int {
+	var n int
+	for _, adj := range s.Adjustments {
+		if adj > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// adjustStep parses the duration given to extend or shorten, defaulting to
+// the extend config.
+func adjustStep(arg string) (time.Duration, error) {
+	if arg == "" {
+		arg = conf.QueryStringOr("extend", Extend)
+	}
+	d, err := time.ParseDuration(arg)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("error: the input must be like 1m, 1h, 1s, 1h30m, etc")
+	}
+	return d, nil
+}
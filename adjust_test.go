@@ -0,0 +1,120 @@
+package pomo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAdjustEmptyLog(t *testing.T) {
+	setupTest(t, time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), nil)
+	writeSessions(t)
+
+	var s Session
+	if err := s.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Adjust(5 * time.Minute); err == nil {
+		t.Error("Adjust() on an empty log succeeded")
+	}
+	if err := s.Snooze(5 * time.Minute); err == nil {
+		t.Error("Snooze() on an empty log succeeded")
+	}
+
+	if sessions, _ := ListSessions(); len(sessions) != 0 {
+		t.Errorf("sessions = %+v, want none", sessions)
+	}
+}
+
+func TestSnoozeIsNotAnExtension(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupTest(t, start.Add(10*time.Minute), map[string]any{"max_extensions": 1})
+	writeSessions(t, Session{ID: uuid.New(), StartTime: start, Duration: 25 * time.Minute, Type: WorkSession})
+
+	var s Session
+	if err := s.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Snooze(5 * time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Snooze(5 * time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Extensions(); got != 0 {
+		t.Errorf("Extensions() after snoozing = %d, want 0", got)
+	}
+	if err := s.Adjust(5 * time.Minute); err != nil {
+		t.Fatalf("Adjust() after snoozing = %v, want the extension allowed", err)
+	}
+
+	// read back from the log
+	var saved Session
+	if err := saved.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Duration != 40*time.Minute || saved.Planned() != 25*time.Minute || len(saved.Snoozes) != 2 || saved.Extensions() != 1 {
+		t.Errorf("saved session = duration %s, planned %s, snoozes %v, extensions %d",
+			saved.Duration, saved.Planned(), saved.Snoozes, saved.Extensions())
+	}
+	if err := saved.Adjust(5 * time.Minute); err == nil {
+		t.Error("Adjust() past max_extensions succeeded")
+	}
+}
+
+func TestSplitTime(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupTest(t, start.Add(2*time.Hour), nil)
+
+	tests := []struct {
+		name        string
+		ran         time.Duration
+		adjustments []time.Duration
+		snoozes     []time.Duration
+		planned     time.Duration
+		extended    time.Duration
+	}{
+		{"as planned", 25 * time.Minute, nil, nil, 25 * time.Minute, 0},
+		{"abandoned", 10 * time.Minute, []time.Duration{5 * time.Minute}, nil, 10 * time.Minute, 0},
+		{"in the extension", 28 * time.Minute, []time.Duration{5 * time.Minute}, nil, 25 * time.Minute, 3 * time.Minute},
+		{"extended and snoozed", 40 * time.Minute, []time.Duration{5 * time.Minute}, []time.Duration{5 * time.Minute}, 25 * time.Minute, 10 * time.Minute},
+		{"shortened", 20 * time.Minute, []time.Duration{-5 * time.Minute}, nil, 20 * time.Minute, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Session{StartTime: start, EndTime: start.Add(tt.ran), Duration: 25 * time.Minute, Type: WorkSession,
+				Adjustments: tt.adjustments, Snoozes: tt.snoozes}
+			for _, d := range append(tt.adjustments, tt.snoozes...) {
+				s.Duration += d
+			}
+
+			planned, extended := s.splitTime()
+			if planned != tt.planned || extended != tt.extended {
+				t.Errorf("splitTime() = %s, %s, want %s, %s", planned, extended, tt.planned, tt.extended)
+			}
+		})
+	}
+}
+
+func TestReportExtended(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupTest(t, start.Add(2*time.Hour), nil)
+	writeSessions(t,
+		Session{ID: uuid.New(), StartTime: start, EndTime: start.Add(25 * time.Minute), Duration: 25 * time.Minute, Type: WorkSession},
+		Session{ID: uuid.New(), StartTime: start.Add(30 * time.Minute), EndTime: start.Add(60 * time.Minute), Duration: 30 * time.Minute,
+			Type: WorkSession, Adjustments: []time.Duration{5 * time.Minute}},
+	)
+
+	from, to := dayBounds(start)
+	out := captureStdout(t, func() error { return Report(from, to, false) })
+
+	if !strings.Contains(out, "[25m planned +05m extended]") {
+		t.Errorf("Report() does not split the extended session:\n%s", out)
+	}
+	if !strings.Contains(out, "Planned 50m, extended 05m") {
+		t.Errorf("Report() does not total the planned and extended time:\n%s", out)
+	}
+}
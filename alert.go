@@ -0,0 +1,218 @@
+package pomo
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Snooze is the default time a snooze adds to the running session.
+	Snooze = "5m"
+	// AlertInterval is the default time between repeated expiry alerts.
+	AlertInterval = "2m"
+	// AlertMaxRepeats is the default number of repeated expiry alerts.
+	AlertMaxRepeats = 3
+)
+
+// Events an alert is sent for.
+const (
+	EventWarning  = "warning"
+	EventWorkEnd  = "work_end"
+	EventBreakEnd = "break_end"
+)
+
+// alert is a notification due for a session.
+type alert struct {
+	event   string
+	title   string
+	message string
+	urgency string
+	sound   bool
+}
+
+// alerter decides when to notify about a session. Before expiry a warning
+// is sent as the remaining time crosses each of the warn_thresholds, with a
+// soft sound when warn_sound is set. The first expiry alert
+// is sent on expiry and, while the session keeps overrunning, it is
+// repeated with increasing urgency following the escalation policy in the
+// config:
+//
+//	alert_intervals   ["2m", "5m"]   wait before each repeat, the last one is reused
+//	alert_max_repeats 3              repeats after the first alert
+//	alert_urgency     ["normal", "critical"] urgency per alert, the last one is reused
+type alerter struct {
+	session  uuid.UUID
+	duration time.Duration
+	warned   int       // warn thresholds crossed
+	sent     int       // alerts sent since expiry
+	next     time.Time // when the next alert is due
+}
+
+// Reset forgets the alerts sent so far.
+func (a *alerter) Reset() {
+	*a = alerter{}
+}
+
+// Check returns the alert due for the session at now, if any.
+func (a *alerter) Check(s Session, now time.Time) *alert {
+	if s.ID == uuid.Nil || !s.isRunning() {
+		return nil
+	}
+
+	// a new, extended or snoozed session starts over
+	if s.ID != a.session || s.Duration != a.duration {
+		a.Reset()
+		a.session = s.ID
+		a.duration = s.Duration
+	}
+
+	end := s.plannedEnd()
+	if now.Before(end) {
+		return a.warning(s, end.Sub(now))
+	}
+	if now.Before(a.next) {
+		return nil
+	}
+	if a.sent > conf.QueryInt("alert_max_repeats", AlertMaxRepeats) {
+		return nil
+	}
+
+	intervals := configDurations("alert_intervals", AlertInterval)
+	a.next = now.Add(intervals[min(a.sent, len(intervals)-1)])
+
+	urgencies := conf.QueryStrings("alert_urgency")
+	if len(urgencies) == 0 {
+		urgencies = []string{UrgencyNormal, UrgencyNormal, UrgencyCritical}
+	}
+	urgency := urgencies[min(a.sent, len(urgencies)-1)]
+
+	event := EventWorkEnd
+	message := "Time to take a break!"
+	switch {
+	case s.Name != "":
+		event = EventBreakEnd
+		message = fmt.Sprintf("Timer %s is done!", s.Name)
+	case s.Type != WorkSession:
+		event = EventBreakEnd
+		message = "Break is over! Time to focus!"
+	}
+	if a.sent > 0 {
+		message = fmt.Sprintf("%s You are %s over.", message, formatDurationHm(now.Sub(end)))
+	}
+	a.sent++
+
+	return &alert{
+		event:   event,
+		title:   "Pomo Timer",
+		message: message,
+		urgency: urgency,
+		sound:   urgency != UrgencyLow,
+	}
+}
+
+// warning returns the warning due with remaining time left, if any. Only
+// the smallest threshold crossed is reported so starting late does not send
+// a burst of warnings.
+func (a *alerter) warning(s Session, remaining time.Duration) *alert {
+	if s.Name != "" {
+		return nil
+	}
+	thresholds := warnThresholds()
+
+	crossed := -1
+	for i, threshold := range thresholds {
+		if remaining <= threshold {
+			crossed = i
+		}
+	}
+	if crossed < a.warned {
+		return nil
+	}
+	a.warned = crossed + 1
+
+	left := formatDurationHm(thresholds[crossed])
+	message := fmt.Sprintf("%s left, start wrapping up.", left)
+	if s.Type != WorkSession {
+		message = fmt.Sprintf("%s of break left, get ready to focus.", left)
+	}
+
+	return &alert{
+		event:   EventWarning,
+		title:   "Pomo Timer",
+		message: message,
+		urgency: UrgencyLow,
+		sound:   conf.QueryBool("warn_sound"),
+	}
+}
+
+// warnThresholds returns the warn_thresholds from the config, longest first.
+func warnThresholds() []time.Duration {
+	thresholds := configDurations("warn_thresholds", Warn)
+	sort.Slice(thresholds, func(i, j int) bool {
+		return thresholds[i] > thresholds[j]
+	})
+	return thresholds
+}
+
+// send delivers the alert in the background.
+func (a *alert) send() {
+	go func() {
+		if err := sendNotification(a.title, a.message, a.urgency); err != nil {
+			fmt.Printf("Failed to send notification: %v\n", err)
+		}
+		if !a.sound {
+			return
+		}
+		if err := playSound(a.event); err != nil {
+			fmt.Printf("Failed to play alert: %v\n", err)
+		}
+	}()
+}
+
+// Snooze silences the alerts of the running session by extending it. Unlike
+// extend it is not limited by max_extensions, and does not count toward it.
+func (s *Session) Snooze(d time.Duration) error {
+	if s.ID == uuid.Nil || !s.isRunning() {
+		return fmt.Errorf("no session is running")
+	}
+
+	// snooze from now when the session already expired
+	if remaining := s.Elapsed(); remaining < 0 {
+		d -= remaining
+	}
+
+	s.Duration += d
+	s.Snoozes = append(s.Snoozes, d)
+	if !s.Until.IsZero() {
+		s.Until = s.Until.Add(d)
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	publish(EventAdjust, *s)
+
+	return nil
+}
+
+// configDurations parses the list of durations under key, falling back to
+// def when the key is missing or invalid.
+func configDurations(key, def string) []time.Duration {
+	var durations []time.Duration
+	for _, val := range conf.QueryStrings(key) {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			continue
+		}
+		durations = append(durations, d)
+	}
+	if len(durations) == 0 {
+		d, _ := time.ParseDuration(def)
+		durations = []time.Duration{d}
+	}
+	return durations
+}
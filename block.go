@@ -0,0 +1,197 @@
+package pomo
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// BlockHostsFile is the default hosts file the blocked domains go to.
+	BlockHostsFile = "/etc/hosts"
+	// BlockSudo runs the write when the hosts file is not writable. It must
+	// not prompt for a password, e.g. with a NOPASSWD rule for tee.
+	BlockSudo = "sudo -n"
+
+	blockBegin = "# BEGIN pomo block"
+	blockEnd   = "# END pomo block"
+)
+
+func init() {
+	hooks = append(hooks, blockHook)
+}
+
+// blockHook blocks the block_domains while a work session runs.
+func blockHook(event string, s Session) error {
+	if s.Name != "" || len(conf.QueryStrings("block_domains")) == 0 {
+		return nil
+	}
+
+	dryRun := conf.QueryBool("block_dry_run")
+	switch {
+	case event == EventStart && s.Type == WorkSession:
+		return Block(true, dryRun)
+	case event == EventStart, event == EventStop:
+		return Block(false, dryRun)
+	}
+	return nil
+}
+
+// Block writes the block_domains into the marked block of the hosts file,
+// or removes the block when on is false. Lines outside the markers are
+// never touched and nothing is written when the file already matches. With
+// dryRun the changes are printed instead.
+func Block(on, dryRun bool) error {
+	path := conf.QueryStringOr("block_hosts_file", BlockHostsFile)
+
+	current, err := Read(path)
+	if err != nil {
+		return err
+	}
+
+	var domains []string
+	if on {
+		domains = conf.QueryStrings("block_domains")
+	}
+
+	updated, err := replaceBlock(current, blockLines(domains))
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if updated == current {
+		return nil
+	}
+
+	if dryRun {
+		fmt.Printf("--- %s\n+++ %s\n", path, path)
+		for _, line := range markedBlock(current) {
+			fmt.Println("-" + line)
+		}
+		for _, line := range markedBlock(updated) {
+			fmt.Println("+" + line)
+		}
+		return nil
+	}
+
+	return writeHosts(path, updated)
+}
+
+// BlockStatus returns the domains currently blocked in the hosts file.
+func BlockStatus() ([]string, error) {
+	current, err := Read(conf.QueryStringOr("block_hosts_file", BlockHostsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var domains []string
+	for _, line := range markedBlock(current) {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "0.0.0.0" {
+			domains = append(domains, fields[1])
+		}
+	}
+	return domains, nil
+}
+
+func blockLines(domains []string) []string {
+	if len(domains) == 0 {
+		return nil
+	}
+
+	lines := []string{blockBegin}
+	for _, domain := range domains {
+		lines = append(lines, "0.0.0.0 "+domain, ":: "+domain)
+	}
+	return append(lines, blockEnd)
+}
+
+// replaceBlock swaps the marked block of content with block, appending it
+// when there is none. An empty block removes the markers. A begin marker
+// without an end marker is an error, as the end of the block is unknown.
+func replaceBlock(content string, block []string) (string, error) {
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	var kept []string
+	inside := false
+	at := -1
+	for _, line := range lines {
+		switch {
+		case line == blockBegin:
+			inside = true
+			at = len(kept)
+		case line == blockEnd && inside:
+			inside = false
+		case !inside:
+			kept = append(kept, line)
+		}
+	}
+	if inside {
+		return "", fmt.Errorf("%q has no matching %q", blockBegin, blockEnd)
+	}
+
+	if at < 0 {
+		at = len(kept)
+	}
+
+	result := append([]string{}, kept[:at]...)
+	result = append(result, block...)
+	result = append(result, kept[at:]...)
+
+	if len(result) == 0 {
+		return "", nil
+	}
+	return strings.Join(result, "\n") + "\n", nil
+}
+
+// markedBlock returns the lines between the markers, markers included.
+func markedBlock(content string) []string {
+	var block []string
+	inside := false
+	for _, line := range strings.Split(content, "\n") {
+		if line == blockBegin {
+			inside = true
+		}
+		if inside {
+			block = append(block, line)
+		}
+		if line == blockEnd {
+			inside = false
+		}
+	}
+	return block
+}
+
+// writeHosts replaces the hosts file with content, directly when possible
+// and through the block_sudo command otherwise. The content goes to a
+// temporary file next to it first, which is then renamed over it, so the
+// hosts file is never left half written.
+func writeHosts(path, content string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".pomo"
+	if err := os.WriteFile(tmp, []byte(content), info.Mode().Perm()); err == nil {
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		return nil
+	}
+
+	sudo := conf.QueryStringOr("block_sudo", BlockSudo)
+	script := fmt.Sprintf("%[1]s tee %[2]s > /dev/null && %[1]s chmod %#[3]o %[2]s && %[1]s mv -f %[2]s %[4]s",
+		sudo, shellQuote(tmp), uint32(info.Mode().Perm()), shellQuote(path))
+	cmd := exec.Command("bash", "-c", script)
+	cmd.Stdin = strings.NewReader(content)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		exec.Command("bash", "-c", fmt.Sprintf("%s rm -f %s", sudo, shellQuote(tmp))).Run()
+		return fmt.Errorf("failed to write %s: %v, output: %s", path, err, string(output))
+	}
+	return nil
+}
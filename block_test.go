@@ -0,0 +1,98 @@
+package pomo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReplaceBlock(t *testing.T) {
+	block := blockLines([]string{"example.com"})
+
+	tests := []struct {
+		name    string
+		content string
+		block   []string
+		want    string
+	}{
+		{
+			name:  "empty file",
+			block: block,
+			want:  blockBegin + "\n0.0.0.0 example.com\n:: example.com\n" + blockEnd + "\n",
+		},
+		{
+			name:    "appended",
+			content: "127.0.0.1 localhost\n",
+			block:   block,
+			want:    "127.0.0.1 localhost\n" + blockBegin + "\n0.0.0.0 example.com\n:: example.com\n" + blockEnd + "\n",
+		},
+		{
+			name:    "replaced in place",
+			content: "a\n" + blockBegin + "\n0.0.0.0 old.com\n" + blockEnd + "\nb\n",
+			block:   block,
+			want:    "a\n" + blockBegin + "\n0.0.0.0 example.com\n:: example.com\n" + blockEnd + "\nb\n",
+		},
+		{
+			name:    "removed",
+			content: "a\n" + blockBegin + "\n0.0.0.0 old.com\n" + blockEnd + "\nb\n",
+			want:    "a\nb\n",
+		},
+		{
+			name:    "removed from an otherwise empty file",
+			content: blockBegin + "\n0.0.0.0 old.com\n" + blockEnd + "\n",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := replaceBlock(tt.content, tt.block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("replaceBlock() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockWithoutEndMarker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts")
+	content := "127.0.0.1 localhost\n" + blockBegin + "\n0.0.0.0 old.com\n::1 localhost\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	setupTest(t, time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), map[string]any{
+		"block_hosts_file": path,
+		"block_domains":    []string{"example.com"},
+	})
+
+	if err := Block(true, false); err == nil {
+		t.Error("Block() with a begin marker and no end marker succeeded")
+	}
+	if got, _ := Read(path); got != content {
+		t.Errorf("hosts file changed to %q", got)
+	}
+}
+
+func TestWriteHosts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts")
+	if err := os.WriteFile(path, []byte("127.0.0.1 localhost\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeHosts(path, "127.0.0.1 localhost\n0.0.0.0 example.com\n"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := Read(path); got != "127.0.0.1 localhost\n0.0.0.0 example.com\n" {
+		t.Errorf("hosts file = %q", got)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("hosts file mode = %v, %v, want 0644", info.Mode().Perm(), err)
+	}
+	if _, err := os.Stat(path + ".pomo"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package pomo
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time. All time dependent logic reads from the
+// package clock instead of calling time.Now so it can be simulated.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+var clock Clock = realClock{}
+
+// SetClock replaces the clock used by pomo and returns the previous one.
+func SetClock(c Clock) Clock {
+	prev := clock
+	clock = c
+	return prev
+}
+
+// since is time.Since on the package clock.
+func since(t time.Time) time.Duration { return clock.Now().Sub(t) }
+
+// FakeClock is a Clock that only moves when told to.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set moves the clock to t.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,143 @@
+package pomo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// setupTest points the config and session log at a temporary directory
+// with the given config, and the package clock at a fake clock at now.
+func setupTest(t *testing.T, now time.Time, config map[string]any) *FakeClock {
+	t.Helper()
+
+	prevConf, prevIndex := conf, index
+	conf = Conf{Id: "pomo", Dir: t.TempDir(), File: "config.json"}
+	index = &sessionIndex{}
+	if err := os.MkdirAll(conf.DirPath(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if config == nil {
+		config = map[string]any{}
+	}
+	buf, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(conf.Path(), buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := NewFakeClock(now)
+	prevClock := SetClock(fake)
+
+	t.Cleanup(func() {
+		conf, index = prevConf, prevIndex
+		SetClock(prevClock)
+	})
+	return fake
+}
+
+// writeSessions writes the sessions as the session log.
+func writeSessions(t *testing.T, sessions ...Session) {
+	t.Helper()
+
+	lines := make([]string, 0, len(sessions))
+	for _, s := range sessions {
+		lines = append(lines, s.String())
+	}
+	path := filepath.Join(conf.DirPath(), SESSION_FILENAME)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func lisbon(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Europe/Lisbon")
+	if err != nil {
+		t.Skipf("no Europe/Lisbon time zone: %v", err)
+	}
+	return loc
+}
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	fake := NewFakeClock(start)
+
+	fake.Advance(90 * time.Second)
+	if got, want := fake.Now(), start.Add(90*time.Second); !got.Equal(want) {
+		t.Errorf("Now() after Advance = %v, want %v", got, want)
+	}
+
+	later := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	fake.Set(later)
+	if got := fake.Now(); !got.Equal(later) {
+		t.Errorf("Now() after Set = %v, want %v", got, later)
+	}
+}
+
+func TestElapsedExpiry(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	fake := setupTest(t, start, nil)
+
+	s := Session{ID: uuid.New(), StartTime: start, Duration: 25 * time.Minute, Type: WorkSession}
+
+	tests := []struct {
+		at   time.Duration
+		want time.Duration
+	}{
+		{0, 25 * time.Minute},
+		{24 * time.Minute, time.Minute},
+		{25 * time.Minute, 0},
+		{27 * time.Minute, -2 * time.Minute},
+	}
+	for _, tt := range tests {
+		fake.Set(start.Add(tt.at))
+		if got := s.Elapsed(); got != tt.want {
+			t.Errorf("Elapsed() at +%s = %s, want %s", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestAlerterExpiry(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupTest(t, start, map[string]any{
+		"warn_thresholds":   []string{"1m"},
+		"alert_intervals":   []string{"2m"},
+		"alert_max_repeats": 1,
+	})
+
+	s := Session{ID: uuid.New(), StartTime: start, Duration: 25 * time.Minute, Type: WorkSession}
+	var a alerter
+
+	steps := []struct {
+		at    time.Duration
+		event string // "" when no alert is due
+	}{
+		{10 * time.Minute, ""},
+		{24*time.Minute + 30*time.Second, EventWarning},
+		{24*time.Minute + 40*time.Second, ""},
+		{25 * time.Minute, EventWorkEnd},
+		{26 * time.Minute, ""},
+		{27 * time.Minute, EventWorkEnd},
+		{30 * time.Minute, ""},
+	}
+	for _, step := range steps {
+		got := a.Check(s, start.Add(step.at))
+		switch {
+		case step.event == "" && got != nil:
+			t.Errorf("Check() at +%s = %s, want no alert", step.at, got.event)
+		case step.event != "" && got == nil:
+			t.Errorf("Check() at +%s = nil, want %s", step.at, step.event)
+		case step.event != "" && got.event != step.event:
+			t.Errorf("Check() at +%s = %s, want %s", step.at, got.event, step.event)
+		}
+	}
+}
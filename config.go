@@ -108,6 +108,48 @@ func (c Conf) QueryString(key string) string {
 	return ""
 }
 
+// QueryStringOr returns the string value of key or def when the key is
+// missing, empty or not a string.
+func (c Conf) QueryStringOr(key, def string) string {
+	if val, ok := c.Query(key).(string); ok && val != "" {
+		return val
+	}
+	return def
+}
+
+// QueryInt returns the numeric value of key or def when the key is missing
+// or not a number.
+func (c Conf) QueryInt(key string, def int) int {
+	if val, ok := c.Query(key).(float64); ok {
+		return int(val)
+	}
+	return def
+}
+
+// QueryBool returns the boolean value of key, false when it is missing.
+func (c Conf) QueryBool(key string) bool {
+	val, _ := c.Query(key).(bool)
+	return val
+}
+
+// QueryStrings returns the list of strings under key, a single string is
+// returned as a list of one.
+func (c Conf) QueryStrings(key string) []string {
+	switch val := c.Query(key).(type) {
+	case string:
+		return []string{val}
+	case []interface{}:
+		list := make([]string, 0, len(val))
+		for _, v := range val {
+			if s, ok := v.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
 func (c Conf) Print() error {
 	var prettyJSON bytes.Buffer
 	if err := json.Indent(&prettyJSON, c.Data(), "", "    "); err != nil {
@@ -0,0 +1,112 @@
+package pomo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const focusFilename = "focus"
+
+// contextProvider returns the file being worked on and when it was last
+// reported, or an empty file when it does not know.
+type contextProvider func() (string, time.Time, error)
+
+// contextProviders are tried in the order of the context_providers config,
+// the first one knowing the file wins:
+//
+//	focus    the file reported by `pomo focus` and editors on `pomo rpc`
+//	nvim     ~/.nvim-buf written by older Neovim setups
+//	command  the output of the context_command config
+var contextProviders = map[string]contextProvider{
+	"focus": fileProvider(func() (string, error) {
+		return filepath.Join(conf.DirPath(), focusFilename), nil
+	}),
+	"nvim": fileProvider(func() (string, error) {
+		homedir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(homedir, ".nvim-buf"), nil
+	}),
+	"command": func() (string, time.Time, error) {
+		command := conf.QueryStringOr("context_command", "")
+		if command == "" {
+			return "", time.Time{}, nil
+		}
+		file, err := ExecOutput(command)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		return strings.TrimSpace(file), clock.Now(), nil
+	},
+}
+
+// DefaultContextProviders are used when context_providers is not set.
+var DefaultContextProviders = []string{"focus", "nvim"}
+
+// activeFile returns the file being worked on and when it was reported,
+// asking the configured context providers. It returns an empty file when
+// none of them knows. A failing provider is reported and the next one is
+// asked, the file is only a hint and must not keep a session from starting.
+func activeFile() (string, time.Time) {
+	file, at, errs := lookupActiveFile()
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return file, at
+}
+
+// lookupActiveFile is activeFile returning the errors of the providers
+// instead of reporting them.
+func lookupActiveFile() (string, time.Time, []error) {
+	names := conf.QueryStrings("context_providers")
+	if len(names) == 0 {
+		names = DefaultContextProviders
+	}
+
+	var errs []error
+	for _, name := range names {
+		provider, ok := contextProviders[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown context provider %q", name))
+			continue
+		}
+		file, at, err := provider()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s context provider: %w", name, err))
+			continue
+		}
+		if file != "" {
+			return file, at, errs
+		}
+	}
+
+	return "", time.Time{}, errs
+}
+
+// fileProvider reads the file name written in the file at path.
+func fileProvider(path func() (string, error)) contextProvider {
+	return func() (string, time.Time, error) {
+		p, err := path()
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return "", time.Time{}, nil
+		}
+		file, err := Read(p)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		return strings.TrimSpace(file), info.ModTime(), nil
+	}
+}
+
+// setActiveFile records the file reported with `pomo focus` or by an editor.
+func setActiveFile(file string) error {
+	return Write(filepath.Join(conf.DirPath(), focusFilename), file)
+}
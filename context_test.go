@@ -0,0 +1,104 @@
+package pomo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestActiveFileSkipsFailingProviders(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupTest(t, now, map[string]any{
+		"context_providers": []string{"missing", "command", "focus"},
+		"context_command":   "exit 1",
+	})
+	if err := setActiveFile("main.go"); err != nil {
+		t.Fatal(err)
+	}
+
+	if file, _ := activeFile(); file != "main.go" {
+		t.Errorf("activeFile() = %q, want the file of the focus provider", file)
+	}
+}
+
+func TestStartWithFailingProvider(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupTest(t, now, map[string]any{
+		"context_providers": []string{"command"},
+		"context_command":   "exit 1",
+	})
+	writeSessions(t)
+
+	var s Session
+	if err := s.Start(conf, 25*time.Minute, WorkSession); err != nil {
+		t.Fatalf("Start() = %v, want the session started without a file", err)
+	}
+	if s.File != "" {
+		t.Errorf("File = %q, want empty", s.File)
+	}
+}
+
+func TestTrackFocus(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	fake := setupTest(t, start, map[string]any{
+		"context_providers": []string{"command"},
+		"context_command":   "echo main.go",
+	})
+	s := Session{ID: uuid.New(), StartTime: start, Duration: 25 * time.Minute, Type: WorkSession, File: "main.go"}
+	writeSessions(t, s)
+
+	fake.Advance(time.Minute)
+	if err := s.trackFocus(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Focus) != 0 {
+		t.Fatalf("Focus = %+v, want no change while on the same file", s.Focus)
+	}
+
+	if err := conf.Set("context_command", "echo ui.go"); err != nil {
+		t.Fatal(err)
+	}
+	fake.Advance(time.Minute)
+	for i := 0; i < 2; i++ {
+		if err := s.trackFocus(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var saved Session
+	if err := saved.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Focus) != 1 || saved.Focus[0].File != "ui.go" || !saved.Focus[0].At.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("Focus = %+v, want a single switch to ui.go at 10:02", saved.Focus)
+	}
+}
+
+func TestResetClearsFocus(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	fake := setupTest(t, start, nil)
+	s := Session{ID: uuid.New(), StartTime: start, Duration: 25 * time.Minute, Type: WorkSession, File: "main.go"}
+	writeSessions(t, s)
+
+	fake.Advance(5 * time.Minute)
+	if err := s.FocusOn("ui.go"); err != nil {
+		t.Fatal(err)
+	}
+	fake.Advance(5 * time.Minute)
+	if err := s.Reset(); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved Session
+	if err := saved.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if !saved.StartTime.Equal(start.Add(10*time.Minute)) || saved.File != "ui.go" || len(saved.Focus) != 0 {
+		t.Errorf("reset session starts at %s on %q with focus %+v, want 10:10 on ui.go without changes",
+			saved.StartTime.Format("15:04"), saved.File, saved.Focus)
+	}
+	if segments := saved.Segments(); len(segments) != 1 || segments[0].From.Before(saved.StartTime) {
+		t.Errorf("Segments() = %+v, want one segment from the new start", segments)
+	}
+}
@@ -0,0 +1,92 @@
+package pomo
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const daemonPidFilename = "daemon.pid"
+
+// Daemon watches the current session without a UI and sends the warning
+// and expiry alerts, so they also fire when the TUI is not open. It also
+// retries the webhook outbox every minute. Its pid is kept in the config
+// directory while it runs, for the TUI to leave the alerts to it and for a
+// second daemon to refuse to start.
+func Daemon() error {
+	if daemonRunning() {
+		return fmt.Errorf("the daemon is already running")
+	}
+	if err := os.WriteFile(daemonPidPath(), []byte(strconv.Itoa(os.Getpid())), DefaultPerms); err != nil {
+		return err
+	}
+	defer Remove(daemonPidPath())
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	alerts := alerters{}
+	for i := 0; ; i++ {
+		var session Session
+		if err := session.Get(); err != nil {
+			fmt.Printf("Failed to get current session: %v\n", err)
+		} else {
+			now := clock.Now()
+			watched := watchedSessions(session)
+			for _, a := range alerts.Check(watched, now) {
+				a.send()
+			}
+			if _, err := endExpiredTimers(watched[1:], now); err != nil {
+				fmt.Printf("Failed to end the expired timers: %v\n", err)
+			}
+			if i%focusEvery == 0 {
+				if err := session.trackFocus(); err != nil {
+					fmt.Printf("Failed to record the focus change: %v\n", err)
+				}
+			}
+		}
+
+		// retry webhooks that failed while offline
+		if i%60 == 0 {
+			if _, err := FlushWebhooks(); err != nil {
+				fmt.Printf("Failed to deliver webhooks: %v\n", err)
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return nil
+		}
+	}
+}
+
+// daemonRunning tells whether another process runs the daemon.
+func daemonRunning() bool {
+	buf, err := os.ReadFile(daemonPidPath())
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf)))
+	if err != nil || pid == os.Getpid() {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// signal 0 only checks that the process exists
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+func daemonPidPath() string {
+	return filepath.Join(conf.DirPath(), daemonPidFilename)
+}
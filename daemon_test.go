@@ -0,0 +1,45 @@
+package pomo
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDaemonRunning(t *testing.T) {
+	setupTest(t, time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), nil)
+
+	if daemonRunning() {
+		t.Error("daemonRunning() without a pid file")
+	}
+
+	// a process that is alive
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Skip(err)
+	}
+	defer cmd.Process.Kill()
+	if err := os.WriteFile(daemonPidPath(), []byte(strconv.Itoa(cmd.Process.Pid)), DefaultPerms); err != nil {
+		t.Fatal(err)
+	}
+	if !daemonRunning() {
+		t.Error("daemonRunning() = false with a live daemon")
+	}
+
+	// left behind by a daemon that was killed
+	cmd.Process.Kill()
+	cmd.Wait()
+	if daemonRunning() {
+		t.Error("daemonRunning() = true after the daemon exited")
+	}
+
+	// the daemon itself
+	if err := os.WriteFile(daemonPidPath(), []byte(strconv.Itoa(os.Getpid())), DefaultPerms); err != nil {
+		t.Fatal(err)
+	}
+	if daemonRunning() {
+		t.Error("daemonRunning() = true for the current process")
+	}
+}
@@ -0,0 +1,123 @@
+package pomo
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DayStart is the local time at which a new day begins for summaries.
+	DayStart = "00:00"
+	// MaxSession caps how long a session left running is counted for.
+	MaxSession = "4h"
+)
+
+// dayBounds returns the start and end of the day containing t. Days begin
+// at the configured day_start so late sessions of night owls still count
+// toward the previous day. Wall clock dates are used, so days crossing a
+// DST transition are 23 or 25 hours long.
+func dayBounds(t time.Time) (time.Time, time.Time) {
+	hour, minute := dayStart()
+
+	y, m, d := t.Date()
+	start := time.Date(y, m, d, hour, minute, 0, 0, t.Location())
+	if t.Before(start) {
+		start = time.Date(y, m, d-1, hour, minute, 0, 0, t.Location())
+	}
+
+	y, m, d = start.Date()
+	end := time.Date(y, m, d+1, hour, minute, 0, 0, t.Location())
+
+	return start, end
+}
+
+func dayStart() (int, int) {
+	t, err := time.Parse("15:04", conf.QueryStringOr("day_start", DayStart))
+	if err != nil {
+		return 0, 0
+	}
+	return t.Hour(), t.Minute()
+}
+
+// maxSession returns the longest duration a running session is counted for.
+func maxSession() time.Duration {
+	d, err := time.ParseDuration(conf.QueryStringOr("max_session", MaxSession))
+	if err != nil || d <= 0 {
+		d, _ = time.ParseDuration(MaxSession)
+	}
+	return d
+}
+
+// sessionEnd returns when the session ended. Running sessions end now, but
+// no later than max_session after their start so a forgotten session does
+// not keep inflating the totals.
+func sessionEnd(s Session) time.Time {
+	if !s.EndTime.IsZero() {
+		return s.EndTime
+	}
+
+	end := clock.Now()
+	if limit := s.StartTime.Add(maxSession()); end.After(limit) {
+		end = limit
+	}
+	return end
+}
+
+// overlap returns how much of the session falls between from and to.
+func overlap(s Session, from, to time.Time) time.Duration {
+	return overlapRange(s.StartTime, sessionEnd(s), from, to)
+}
+
+// overlapRange returns how much of [start, end) falls between from and to.
+func overlapRange(start, end, from, to time.Time) time.Duration {
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// dayRange parses the YYYY-MM-DD days of the --from and --to flags into the
+// start of the first day and the end of the last one. An empty from starts
+// at the beginning of the log and an empty to ends today.
+func dayRange(fromDay, toDay string) (time.Time, time.Time, error) {
+	var from time.Time
+	if fromDay != "" {
+		day, err := time.ParseInLocation("2006-01-02", fromDay, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("error: --from must be like 2006-01-02")
+		}
+		// noon is always within the day, whatever the day_start
+		from, _ = dayBounds(day.Add(12 * time.Hour))
+	}
+
+	_, to := dayBounds(clock.Now())
+	if toDay != "" {
+		day, err := time.ParseInLocation("2006-01-02", toDay, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("error: --to must be like 2006-01-02")
+		}
+		_, to = dayBounds(day.Add(12 * time.Hour))
+	}
+
+	return from, to, nil
+}
+
+// parseDay returns a time within the day given like 2006-01-02, or now when
+// day is empty.
+func parseDay(day string) (time.Time, error) {
+	if day == "" {
+		return clock.Now(), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error: --day must be like 2006-01-02")
+	}
+	// noon is always within the day, whatever the day_start
+	return t.Add(12 * time.Hour), nil
+}
@@ -0,0 +1,164 @@
+package pomo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDayBounds(t *testing.T) {
+	utc := time.UTC
+
+	tests := []struct {
+		name     string
+		dayStart string
+		at       time.Time
+		from, to time.Time
+	}{
+		{
+			name: "before midnight",
+			at:   time.Date(2024, 1, 2, 23, 59, 0, 0, utc),
+			from: time.Date(2024, 1, 2, 0, 0, 0, 0, utc),
+			to:   time.Date(2024, 1, 3, 0, 0, 0, 0, utc),
+		},
+		{
+			name: "after midnight",
+			at:   time.Date(2024, 1, 3, 0, 1, 0, 0, utc),
+			from: time.Date(2024, 1, 3, 0, 0, 0, 0, utc),
+			to:   time.Date(2024, 1, 4, 0, 0, 0, 0, utc),
+		},
+		{
+			name:     "before day_start counts toward the previous day",
+			dayStart: "04:00",
+			at:       time.Date(2024, 1, 3, 2, 30, 0, 0, utc),
+			from:     time.Date(2024, 1, 2, 4, 0, 0, 0, utc),
+			to:       time.Date(2024, 1, 3, 4, 0, 0, 0, utc),
+		},
+		{
+			name:     "after day_start",
+			dayStart: "04:00",
+			at:       time.Date(2024, 1, 3, 4, 0, 0, 0, utc),
+			from:     time.Date(2024, 1, 3, 4, 0, 0, 0, utc),
+			to:       time.Date(2024, 1, 4, 4, 0, 0, 0, utc),
+		},
+		{
+			name: "new year",
+			at:   time.Date(2024, 12, 31, 12, 0, 0, 0, utc),
+			from: time.Date(2024, 12, 31, 0, 0, 0, 0, utc),
+			to:   time.Date(2025, 1, 1, 0, 0, 0, 0, utc),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{}
+			if tt.dayStart != "" {
+				config["day_start"] = tt.dayStart
+			}
+			setupTest(t, tt.at, config)
+
+			from, to := dayBounds(tt.at)
+			if !from.Equal(tt.from) || !to.Equal(tt.to) {
+				t.Errorf("dayBounds(%v) = %v, %v, want %v, %v", tt.at, from, to, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestDayBoundsDST(t *testing.T) {
+	loc := lisbon(t)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want time.Duration
+	}{
+		// clocks go forward from 01:00 to 02:00
+		{"spring forward", time.Date(2024, 3, 31, 12, 0, 0, 0, loc), 23 * time.Hour},
+		// clocks go back from 02:00 to 01:00
+		{"fall back", time.Date(2024, 10, 27, 12, 0, 0, 0, loc), 25 * time.Hour},
+		{"regular day", time.Date(2024, 10, 28, 12, 0, 0, 0, loc), 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t, tt.at, nil)
+
+			from, to := dayBounds(tt.at)
+			if got := to.Sub(from); got != tt.want {
+				t.Errorf("day of %s lasts %s, want %s", tt.at.Format("2006-01-02"), got, tt.want)
+			}
+			if from.Hour() != 0 || from.Minute() != 0 || to.Hour() != 0 || to.Minute() != 0 {
+				t.Errorf("dayBounds(%v) = %v, %v, want local midnights", tt.at, from, to)
+			}
+		})
+	}
+}
+
+func TestOverlapAcrossMidnight(t *testing.T) {
+	utc := time.UTC
+	start := time.Date(2024, 1, 2, 23, 40, 0, 0, utc)
+	end := time.Date(2024, 1, 3, 0, 25, 0, 0, utc)
+	setupTest(t, end.Add(time.Hour), nil)
+
+	s := Session{ID: uuid.New(), StartTime: start, EndTime: end, Duration: 45 * time.Minute, Type: WorkSession}
+
+	before, _ := dayBounds(start)
+	midnight, after := dayBounds(end)
+
+	if got := overlap(s, before, midnight); got != 20*time.Minute {
+		t.Errorf("overlap with the first day = %s, want 20m", got)
+	}
+	if got := overlap(s, midnight, after); got != 25*time.Minute {
+		t.Errorf("overlap with the second day = %s, want 25m", got)
+	}
+
+	types, _ := summarizeSessions([]Session{s}, midnight, after)
+	if got := types[WorkSession]; got != 25*time.Minute {
+		t.Errorf("work summarized on the second day = %s, want 25m", got)
+	}
+}
+
+func TestOverlapDayStart(t *testing.T) {
+	utc := time.UTC
+	// a late session that belongs to the day before with day_start at 04:00
+	start := time.Date(2024, 1, 3, 1, 0, 0, 0, utc)
+	end := start.Add(25 * time.Minute)
+	setupTest(t, end, map[string]any{"day_start": "04:00"})
+
+	s := Session{ID: uuid.New(), StartTime: start, EndTime: end, Duration: 25 * time.Minute, Type: WorkSession}
+
+	from, to := dayBounds(time.Date(2024, 1, 2, 12, 0, 0, 0, utc))
+	if got := overlap(s, from, to); got != 25*time.Minute {
+		t.Errorf("overlap with 2024-01-02 = %s, want 25m", got)
+	}
+}
+
+func TestOverlapDST(t *testing.T) {
+	loc := lisbon(t)
+	// 00:30 to 02:30 on the spring forward day only lasts an hour
+	start := time.Date(2024, 3, 31, 0, 30, 0, 0, loc)
+	end := time.Date(2024, 3, 31, 2, 30, 0, 0, loc)
+	setupTest(t, end, nil)
+
+	s := Session{ID: uuid.New(), StartTime: start, EndTime: end, Duration: time.Hour, Type: WorkSession}
+
+	from, to := dayBounds(start)
+	if got := overlap(s, from, to); got != time.Hour {
+		t.Errorf("overlap = %s, want 1h", got)
+	}
+}
+
+func TestOverlapRunningIsCapped(t *testing.T) {
+	utc := time.UTC
+	start := time.Date(2024, 1, 2, 8, 0, 0, 0, utc)
+	setupTest(t, start.Add(10*time.Hour), map[string]any{"max_session": "4h"})
+
+	s := Session{ID: uuid.New(), StartTime: start, Duration: 25 * time.Minute, Type: WorkSession}
+
+	from, to := dayBounds(start)
+	if got := overlap(s, from, to); got != 4*time.Hour {
+		t.Errorf("overlap of a forgotten session = %s, want max_session", got)
+	}
+}
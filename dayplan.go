@@ -0,0 +1,240 @@
+package pomo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const planFilename = "plan.json"
+
+// plannedTask is a task planned for a day with the number of pomodoros it
+// is estimated to take.
+type plannedTask struct {
+	Task     string `json:"task"`
+	Estimate int    `json:"estimate"`
+}
+
+// PlanTask adds the task to today's plan, or changes its estimate when it
+// is already planned.
+func PlanTask(task string, estimate int) error {
+	if task == "" {
+		return fmt.Errorf("the task cannot be empty")
+	}
+	if estimate <= 0 {
+		return fmt.Errorf("the estimate must be at least 1 pomodoro")
+	}
+
+	plans, err := readPlans()
+	if err != nil {
+		return err
+	}
+
+	day := planDayKey(clock.Now())
+	tasks := plans[day]
+	for i := range tasks {
+		if tasks[i].Task == task {
+			tasks[i].Estimate = estimate
+			return writePlans(plans)
+		}
+	}
+	plans[day] = append(tasks, plannedTask{Task: task, Estimate: estimate})
+
+	return writePlans(plans)
+}
+
+// planTaskArgs joins the arguments of `pomo plan add` into the task. The
+// cli stops parsing flags at the first argument, so an --estimate given after
+// the task is picked out here.
+func planTaskArgs(args []string, estimate int) (string, int, error) {
+	var words []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value, isFlag := "", false
+		switch {
+		case arg == "-e" || arg == "--estimate" || arg == "-estimate":
+			if i+1 >= len(args) {
+				return "", 0, fmt.Errorf("error: %s needs a number of pomodoros", arg)
+			}
+			value, isFlag = args[i+1], true
+			i++
+		case strings.HasPrefix(arg, "--estimate="), strings.HasPrefix(arg, "-e="):
+			value, isFlag = arg[strings.Index(arg, "=")+1:], true
+		}
+
+		if !isFlag {
+			words = append(words, arg)
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", 0, fmt.Errorf("error: the estimate must be a number of pomodoros")
+		}
+		estimate = n
+	}
+	return strings.Join(words, " "), estimate, nil
+}
+
+// PlanReview prints the estimated and actual pomodoros of every task planned
+// on the day of t, followed by the tasks worked on without a plan.
+func PlanReview(t time.Time) error {
+	plans, err := readPlans()
+	if err != nil {
+		return err
+	}
+	sessions, err := ListSessions()
+	if err != nil {
+		return err
+	}
+
+	from, to := dayBounds(t)
+	actual := pomodorosPerTask(sessions, from, to)
+	tasks := plans[planDayKey(t)]
+
+	if len(tasks) == 0 && len(actual) == 0 {
+		fmt.Printf("Nothing planned nor done on %s\n", from.Format("2006-01-02"))
+		return nil
+	}
+
+	fmt.Printf("%8s %6s %5s  %s\n", "ESTIMATE", "ACTUAL", "DIFF", "TASK")
+	var estimated, done int
+	for _, p := range tasks {
+		fmt.Printf("%8d %6d %+5d  %s\n", p.Estimate, actual[p.Task], actual[p.Task]-p.Estimate, p.Task)
+		estimated += p.Estimate
+		done += actual[p.Task]
+		delete(actual, p.Task)
+	}
+
+	unplanned := make([]string, 0, len(actual))
+	for task := range actual {
+		unplanned = append(unplanned, task)
+	}
+	sort.Strings(unplanned)
+	for _, task := range unplanned {
+		n := actual[task]
+		if task == "" {
+			task = "(no task)"
+		}
+		fmt.Printf("%8s %6d %5s  %s\n", "-", n, "", task)
+		done += n
+	}
+	fmt.Printf("%8d %6d %+5d  total\n", estimated, done, done-estimated)
+
+	return nil
+}
+
+// plannedProgress returns the current task of today's plan with its
+// progress, e.g. "write design doc (2/4)", or "" when nothing is planned.
+// The current task is the one of the running work session, otherwise the
+// first one that is not done yet.
+func plannedProgress(s Session) string {
+	task, ok := currentPlannedTask(s)
+	if !ok || (s.Type == WorkSession && s.isRunning() && s.Task != task.Task) {
+		return ""
+	}
+
+	sessions, err := ListSessions()
+	if err != nil {
+		return ""
+	}
+	from, to := dayBounds(clock.Now())
+	done := pomodorosPerTask(sessions, from, to)[task.Task]
+
+	return fmt.Sprintf("%s (%d/%d)", task.Task, done, task.Estimate)
+}
+
+// autoPlannedTask returns the task of today's plan a work session started
+// without one is tagged with, when plan_auto_task is set.
+func autoPlannedTask() (string, bool) {
+	if !conf.QueryBool("plan_auto_task") {
+		return "", false
+	}
+	task, ok := currentPlannedTask(Session{})
+	return task.Task, ok
+}
+
+// currentPlannedTask returns the task of today's plan that the session
+// works on, or the first one that did not reach its estimate yet.
+func currentPlannedTask(s Session) (plannedTask, bool) {
+	plans, err := readPlans()
+	if err != nil {
+		return plannedTask{}, false
+	}
+	tasks := plans[planDayKey(clock.Now())]
+	if len(tasks) == 0 {
+		return plannedTask{}, false
+	}
+
+	if s.Type == WorkSession && s.isRunning() {
+		for _, p := range tasks {
+			if p.Task == s.Task {
+				return p, true
+			}
+		}
+	}
+
+	sessions, err := ListSessions()
+	if err != nil {
+		return plannedTask{}, false
+	}
+	from, to := dayBounds(clock.Now())
+	actual := pomodorosPerTask(sessions, from, to)
+	for _, p := range tasks {
+		if actual[p.Task] < p.Estimate {
+			return p, true
+		}
+	}
+	return plannedTask{}, false
+}
+
+// pomodorosPerTask counts the completed pomodoros of each task that started
+// between from and to.
+func pomodorosPerTask(sessions []Session, from, to time.Time) map[string]int {
+	count := map[string]int{}
+	for _, s := range sessions {
+		if !s.isCompleted() || s.StartTime.Before(from) || !s.StartTime.Before(to) {
+			continue
+		}
+		count[s.Task]++
+	}
+	return count
+}
+
+// planDayKey returns the day of t in the plan file, following day_start.
+func planDayKey(t time.Time) string {
+	from, _ := dayBounds(t)
+	return from.Format("2006-01-02")
+}
+
+func readPlans() (map[string][]plannedTask, error) {
+	plans := map[string][]plannedTask{}
+	if !Exists(planPath()) {
+		return plans, nil
+	}
+
+	buf, err := os.ReadFile(planPath())
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, &plans); err != nil {
+		return nil, fmt.Errorf("invalid plan: %w", err)
+	}
+	return plans, nil
+}
+
+func writePlans(plans map[string][]plannedTask) error {
+	buf, err := json.MarshalIndent(plans, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(planPath(), buf, DefaultPerms)
+}
+
+func planPath() string {
+	return filepath.Join(conf.DirPath(), planFilename)
+}
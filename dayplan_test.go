@@ -0,0 +1,56 @@
+package pomo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCompletedPomodoros(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupTest(t, start.Add(3*time.Hour), nil)
+
+	work := func(offset, length time.Duration, task string) Session {
+		s := Session{ID: uuid.New(), StartTime: start.Add(offset), Duration: 25 * time.Minute, Type: WorkSession, Task: task}
+		s.EndTime = s.StartTime.Add(length)
+		return s
+	}
+	extended := work(time.Hour, 28*time.Minute, "docs")
+	extended.Duration, extended.Adjustments = 30*time.Minute, []time.Duration{5 * time.Minute}
+
+	sessions := []Session{
+		work(0, 25*time.Minute, "docs"),              // completed
+		work(30*time.Minute, 10*time.Minute, "docs"), // abandoned
+		extended, // stopped before the end of its extension
+		work(90*time.Minute, 30*time.Minute, "tests"), // overran
+	}
+
+	from, to := dayBounds(start)
+	count := pomodorosPerTask(sessions, from, to)
+	if count["docs"] != 1 || count["tests"] != 1 {
+		t.Errorf("pomodorosPerTask() = %v, want docs 1 and tests 1", count)
+	}
+
+	stats := computeStats(sessions, from, to)
+	if stats.Completed != 2 || stats.Abandoned != 2 {
+		t.Errorf("computeStats() = %d completed and %d abandoned, want 2 and 2", stats.Completed, stats.Abandoned)
+	}
+}
+
+func TestAutoPlannedTask(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	for _, auto := range []bool{false, true} {
+		setupTest(t, now, map[string]any{"plan_auto_task": auto})
+		writeSessions(t)
+		if err := PlanTask("docs", 2); err != nil {
+			t.Fatal(err)
+		}
+
+		task, ok := autoPlannedTask()
+		if ok != auto || (auto && task != "docs") {
+			t.Errorf("autoPlannedTask() with plan_auto_task %v = %q, %v", auto, task, ok)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package pomo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const dndFilename = "dnd.json"
+
+// dndProvider silences the notifications of a desktop. State returns what
+// has to be handed back to Restore to undo Enable.
+type dndProvider struct {
+	State   func() (string, error)
+	Enable  func() error
+	Restore func(state string) error
+}
+
+// dndProviders are the providers that can be listed in the dnd config, e.g.
+//
+//	"dnd": ["dunst", "custom"],
+//	"dnd_on": "notify-off",
+//	"dnd_off": "notify-on"
+var dndProviders = map[string]dndProvider{
+	"dunst": {
+		State: func() (string, error) { return ExecOutput("dunstctl is-paused") },
+		Enable: func() error {
+			_, err := ExecOutput("dunstctl set-paused true")
+			return err
+		},
+		Restore: func(state string) error {
+			_, err := ExecOutput("dunstctl set-paused " + shellQuote(state))
+			return err
+		},
+	},
+	"mako": {
+		State: func() (string, error) { return ExecOutput("makoctl mode") },
+		Enable: func() error {
+			_, err := ExecOutput("makoctl mode -a do-not-disturb")
+			return err
+		},
+		Restore: func(state string) error {
+			modes := strings.Fields(state)
+			for i, mode := range modes {
+				modes[i] = shellQuote(mode)
+			}
+			_, err := ExecOutput("makoctl mode -s " + strings.Join(modes, " "))
+			return err
+		},
+	},
+	"gnome": {
+		State: func() (string, error) {
+			return ExecOutput("gsettings get org.gnome.desktop.notifications show-banners")
+		},
+		Enable: func() error {
+			_, err := ExecOutput("gsettings set org.gnome.desktop.notifications show-banners false")
+			return err
+		},
+		Restore: func(state string) error {
+			_, err := ExecOutput("gsettings set org.gnome.desktop.notifications show-banners " + shellQuote(state))
+			return err
+		},
+	},
+	"custom": {
+		State: func() (string, error) { return "", nil },
+		Enable: func() error {
+			_, err := ExecOutput(conf.QueryStringOr("dnd_on", "true"))
+			return err
+		},
+		Restore: func(string) error {
+			_, err := ExecOutput(conf.QueryStringOr("dnd_off", "true"))
+			return err
+		},
+	},
+}
+
+// dndState is what the providers were set to before a work session, kept
+// on disk so an interrupted pomo can still restore it.
+type dndState struct {
+	Session string            `json:"session"`
+	States  map[string]string `json:"states"`
+}
+
+func init() {
+	hooks = append(hooks, dndHook)
+}
+
+// dndHook enables do not disturb when a work session starts and restores
+// the previous state when it stops or a break starts.
+func dndHook(event string, s Session) error {
+	if s.Name != "" {
+		return nil
+	}
+	switch {
+	case event == EventStart && s.Type == WorkSession:
+		return EnableDND(s)
+	case event == EventStart, event == EventStop:
+		return RestoreDND()
+	}
+	return nil
+}
+
+// EnableDND saves the state of the configured providers and silences them.
+// When do not disturb is already on the first saved state is kept.
+func EnableDND(s Session) error {
+	names := conf.QueryStrings("dnd")
+	if len(names) == 0 || Exists(dndPath()) {
+		return nil
+	}
+
+	state := dndState{
+		Session: s.ID.String(),
+		States:  map[string]string{},
+	}
+	for _, name := range names {
+		provider, ok := dndProviders[name]
+		if !ok {
+			return fmt.Errorf("unknown dnd provider %q", name)
+		}
+		current, err := provider.State()
+		if err != nil {
+			return err
+		}
+		state.States[name] = current
+	}
+
+	// save before changing anything so a crash halfway can be undone
+	buf, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dndPath(), buf, DefaultPerms); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := dndProviders[name].Enable(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreDND puts every provider back to the state saved by EnableDND. The
+// saved state is dropped even when it cannot be restored, so a broken
+// provider or state file does not fail every later command; it is kept as
+// dnd.json.failed to be looked at.
+func RestoreDND() error {
+	if !Exists(dndPath()) {
+		return nil
+	}
+
+	buf, err := os.ReadFile(dndPath())
+	if err != nil {
+		return err
+	}
+	var state dndState
+	if err := json.Unmarshal(buf, &state); err != nil {
+		return discardDND(fmt.Errorf("invalid dnd state: %w", err))
+	}
+
+	var failed error
+	for name, previous := range state.States {
+		provider, ok := dndProviders[name]
+		if !ok {
+			continue
+		}
+		if err := provider.Restore(previous); err != nil && failed == nil {
+			failed = fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+	}
+	if failed != nil {
+		return discardDND(failed)
+	}
+
+	return Remove(dndPath())
+}
+
+// discardDND moves the saved state aside after it could not be restored and
+// returns err.
+func discardDND(err error) error {
+	if renameErr := os.Rename(dndPath(), dndPath()+".failed"); renameErr != nil {
+		Remove(dndPath())
+	}
+	return fmt.Errorf("%w, do not disturb may have to be turned off by hand", err)
+}
+
+// recoverDND restores do not disturb left on by a pomo that was interrupted
+// before the work session stopped. Failures are only reported, as they must
+// not keep the command from running.
+func recoverDND() {
+	if !Exists(dndPath()) {
+		return
+	}
+
+	var session Session
+	if err := session.Get(); err != nil {
+		fmt.Fprintf(os.Stderr, "dnd: %v\n", err)
+		return
+	}
+	if session.Type == WorkSession && session.isRunning() && !session.isStale() {
+		return
+	}
+
+	if err := RestoreDND(); err != nil {
+		fmt.Fprintf(os.Stderr, "dnd: %v\n", err)
+	}
+}
+
+func dndPath() string {
+	return filepath.Join(conf.DirPath(), dndFilename)
+}
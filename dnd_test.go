@@ -0,0 +1,50 @@
+package pomo
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRestoreDNDFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]any
+		state  string
+	}{
+		{
+			name:   "invalid state",
+			config: map[string]any{"dnd": []string{"custom"}},
+			state:  "{",
+		},
+		{
+			name:   "provider fails",
+			config: map[string]any{"dnd": []string{"custom"}, "dnd_off": "false"},
+			state:  `{"session":"","states":{"custom":""}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t, time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), tt.config)
+			if err := os.WriteFile(dndPath(), []byte(tt.state), DefaultPerms); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := RestoreDND(); err == nil {
+				t.Error("RestoreDND() succeeded")
+			}
+			if Exists(dndPath()) {
+				t.Error("the state file was kept after a failed restore")
+			}
+			if got, _ := Read(dndPath() + ".failed"); got != tt.state {
+				t.Errorf("moved state = %q, want %q", got, tt.state)
+			}
+
+			// the next restore has nothing left to do
+			if err := RestoreDND(); err != nil {
+				t.Errorf("second RestoreDND() = %v", err)
+			}
+		})
+	}
+}
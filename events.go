@@ -0,0 +1,35 @@
+package pomo
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+)
+
+// Session events published to the hooks.
+const (
+	EventStart  = "session.start"
+	EventStop   = "session.stop"
+	EventAdjust = "session.adjust"
+)
+
+// hook is notified of every session event. Integrations append themselves
+// to hooks from their init function.
+type hook func(event string, s Session) error
+
+var hooks []hook
+
+// publish runs every hook for the event. A failing hook is reported but does
+// not stop the others nor the session change that triggered it. Nothing is
+// published for the zero session of an empty log.
+func publish(event string, s Session) {
+	if s.ID == uuid.Nil {
+		return
+	}
+	for _, h := range hooks {
+		if err := h(event, s); err != nil {
+			fmt.Fprintf(os.Stderr, "%s hook: %v\n", event, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package pomo
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FocusChange is a switch to another file during a session.
+type FocusChange struct {
+	File string
+	At   time.Time
+}
+
+// FocusOn records that the running session moved to file. Reporting the
+// file already in focus does nothing.
+func (s *Session) FocusOn(file string) error {
+	if !s.isRunning() || file == "" || file == s.currentFile() {
+		return nil
+	}
+
+	s.Focus = append(s.Focus, FocusChange{File: file, At: clock.Now()})
+	return s.Save()
+}
+
+// focusEvery is how many seconds apart the daemon, or the TUI when no
+// daemon runs, asks the context providers for focus changes.
+const focusEvery = 5
+
+// trackFocus records a switch of the running session to the file reported
+// by the context providers after the last change. Failing providers are
+// skipped silently, they were reported when the session started.
+func (s *Session) trackFocus() error {
+	if s.ID == uuid.Nil || !s.isRunning() {
+		return nil
+	}
+
+	file, at, _ := lookupActiveFile()
+	last := s.StartTime
+	if len(s.Focus) > 0 {
+		last = s.Focus[len(s.Focus)-1].At
+	}
+	if !at.After(last) {
+		return nil
+	}
+	return s.FocusOn(file)
+}
+
+// currentFile returns the file the session is focused on.
+func (s *Session) currentFile() string {
+	if len(s.Focus) == 0 {
+		return s.File
+	}
+	return s.Focus[len(s.Focus)-1].File
+}
+
+// fileSegment is a stretch of a session spent on one file.
+type fileSegment struct {
+	File     string
+	From, To time.Time
+}
+
+// Segments splits the session into the time spent on each file, running
+// sessions end at sessionEnd.
+func (s *Session) Segments() []fileSegment {
+	end := sessionEnd(*s)
+
+	segments := []fileSegment{{File: s.File, From: s.StartTime}}
+	for _, f := range s.Focus {
+		at := f.At
+		if at.After(end) {
+			at = end
+		}
+		segments[len(segments)-1].To = at
+		segments = append(segments, fileSegment{File: f.File, From: at})
+	}
+	segments[len(segments)-1].To = end
+
+	return segments
+}
+
+// Focus records file as the one being worked on, for the next session and
+// as a focus change of the running one.
+func Focus(file string) error {
+	if err := setActiveFile(file); err != nil {
+		return err
+	}
+
+	var session Session
+	if err := session.Get(); err != nil {
+		return err
+	}
+	if session.ID == uuid.Nil {
+		return nil
+	}
+	return session.FocusOn(file)
+}
@@ -82,6 +82,34 @@ func Write(path string, text string) error {
   return os.WriteFile(path, []byte(text), 0644)
 }
 
+// WriteReplace writes content to a new file renamed over path, so readers
+// never see it half written and can tell it was replaced. A symlink at path
+// is followed.
+func WriteReplace(path string, text string) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
 // WriteAppend writes content to a file. It will append to the file if it already
 // exists and create the file if it does not.
 func WriteAppend(path string, text string) error {
@@ -135,22 +163,35 @@ func Exists(path string) bool {
 	return !errors.Is(err, fs.ErrNotExist)
 }
 
+// InsertLine appends newLine to the file, after a newline when the last
+// line of the file has none.
 func InsertLine(path, newLine string) error {
-	lines, err := ReadLines(path)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
 	if err != nil {
 		return err
 	}
 
-	fileContent := ""
-	for _, line := range lines {
-		fileContent += line
-		fileContent += "\n"
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+			f.Close()
+			return err
+		}
+		if last[0] != '\n' {
+			newLine = "\n" + newLine
+		}
 	}
 
-	fileContent += newLine
-	fileContent += "\n"
-
-	return os.WriteFile(path, []byte(fileContent), 0644)
+	if _, err := f.WriteString(newLine + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
 
 func InsertLineAtIndex(path, newLine string, index int) error {
@@ -0,0 +1,144 @@
+package pomo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const gitHookMarker = "# installed by pomo"
+
+// gitHook adds a Pomodoro trailer with the id of the running work session
+// to commit messages.
+const gitHook = `#!/bin/sh
+` + gitHookMarker + `: adds the running pomodoro to the commit message
+id=$(pomo print --id 2>/dev/null)
+if [ -n "$id" ]; then
+	git interpret-trailers --in-place --if-exists addIfDifferent --trailer "Pomodoro: $id" "$1"
+fi
+exit 0
+`
+
+// PrintRunningID prints the id of the running work session for the git
+// hook, and nothing otherwise. Unlike Print it never falls back to the
+// print_idle_format.
+func PrintRunningID() error {
+	var session Session
+	if err := session.Get(); err != nil {
+		return err
+	}
+	if session.ID == uuid.Nil || !session.isRunning() || session.Type != WorkSession {
+		return nil
+	}
+
+	fmt.Println(session.ID)
+	return nil
+}
+
+// gitCommit is a commit made during a session.
+type gitCommit struct {
+	Hash    string
+	Subject string
+}
+
+// detectGit returns the repository and branch the file belongs to, empty
+// when it is not inside a git repository or git is missing.
+func detectGit(file string) (string, string) {
+	if file == "" || !hasBinary("git") {
+		return "", ""
+	}
+
+	dir := file
+	if info, err := os.Stat(file); err != nil || !info.IsDir() {
+		dir = filepath.Dir(file)
+	}
+	if !Exists(dir) {
+		return "", ""
+	}
+
+	repo, err := ExecOutput(fmt.Sprintf("git -C %s rev-parse --show-toplevel", shellQuote(dir)))
+	if err != nil {
+		return "", ""
+	}
+	branch, err := ExecOutput(fmt.Sprintf("git -C %s rev-parse --abbrev-ref HEAD", shellQuote(dir)))
+	if err != nil {
+		return repo, ""
+	}
+	return repo, branch
+}
+
+// sessionCommits returns the commits of the session repository authored by
+// the configured git user while the session ran.
+func sessionCommits(s Session) ([]gitCommit, error) {
+	if s.Repo == "" || !Exists(s.Repo) {
+		return nil, nil
+	}
+
+	repo := shellQuote(s.Repo)
+	email, err := ExecOutput(fmt.Sprintf("git -C %s config user.email", repo))
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ExecOutput(fmt.Sprintf("git -C %s log --all --since=%s --until=%s --author=%s --format='%%h %%s'",
+		repo,
+		shellQuote(s.StartTime.Format(time.RFC3339)),
+		shellQuote(sessionEnd(s).Format(time.RFC3339)),
+		shellQuote(email),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []gitCommit
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, " ", 2)
+		commit := gitCommit{Hash: parts[0]}
+		if len(parts) == 2 {
+			commit.Subject = parts[1]
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// InstallGitHook installs the prepare-commit-msg hook adding the Pomodoro
+// trailer in the repository of dir. A hook not installed by pomo is never
+// overwritten.
+func InstallGitHook(dir string) error {
+	hooksDir, err := ExecOutput(fmt.Sprintf("git -C %s rev-parse --git-path hooks", shellQuote(dir)))
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(dir, hooksDir)
+	}
+
+	path := filepath.Join(hooksDir, "prepare-commit-msg")
+	if Exists(path) {
+		current, err := Read(path)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(current, gitHookMarker) {
+			return fmt.Errorf("%s already exists, add the pomo trailer to it by hand", path)
+		}
+	}
+
+	if err := Mkdir(hooksDir); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(gitHook), 0755); err != nil {
+		return err
+	}
+
+	fmt.Printf("Installed %s\n", path)
+	return nil
+}
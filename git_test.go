@@ -0,0 +1,39 @@
+package pomo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPrintRunningID(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	id := uuid.New()
+
+	tests := []struct {
+		name    string
+		session Session
+		want    string
+	}{
+		{"empty log", Session{}, ""},
+		{"idle", Session{ID: id, StartTime: now.Add(-time.Hour), EndTime: now.Add(-35 * time.Minute), Duration: 25 * time.Minute, Type: WorkSession}, ""},
+		{"break", Session{ID: id, StartTime: now.Add(-time.Minute), Duration: 5 * time.Minute, Type: BreakSession}, ""},
+		{"work", Session{ID: id, StartTime: now.Add(-time.Minute), Duration: 25 * time.Minute, Type: WorkSession}, id.String() + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t, now, map[string]any{"print_idle_format": "🍅 idle"})
+			if tt.session.ID == uuid.Nil {
+				writeSessions(t)
+			} else {
+				writeSessions(t, tt.session)
+			}
+
+			if got := captureStdout(t, PrintRunningID); got != tt.want {
+				t.Errorf("PrintRunningID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
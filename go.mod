@@ -1,11 +1,12 @@
 module github.com/odas0r/pomo-cmd
 
-go 1.18
+go 1.21
 
 require (
 	github.com/charmbracelet/bubbletea v1.2.1
 	github.com/charmbracelet/lipgloss v1.0.0
 	github.com/fatih/color v1.16.0
+	github.com/google/uuid v1.6.0
 	github.com/rogpeppe/go-internal v1.9.0
 	github.com/urfave/cli/v2 v2.16.3
 )
@@ -16,7 +17,6 @@ require (
 	github.com/charmbracelet/x/term v0.2.0 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
 	github.com/erikgeiser/coninput v0.0.0-20211004153227-1c3628e74d0f // indirect
-	github.com/google/uuid v1.6.0 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
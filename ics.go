@@ -0,0 +1,403 @@
+package pomo
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// icsEvent is a VEVENT of an iCalendar file, see RFC 5545.
+type icsEvent struct {
+	Summary string
+	Start   time.Time
+	End     time.Time
+
+	uid          string
+	recurrenceID time.Time // zero unless it overrides an occurrence
+	cancelled    bool
+	rule         *icsRule
+	exdates      []time.Time
+}
+
+// icsRule is a DAILY or WEEKLY recurrence rule.
+type icsRule struct {
+	Freq     string
+	Interval int
+	Count    int            // 0 when unbounded
+	Until    time.Time      // zero when unbounded
+	ByDay    []time.Weekday // WEEKLY only, the weekday of the start when empty
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var icsDuration = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseICS returns the timed events of an iCalendar file. All day and
+// cancelled events are skipped as they do not take time out of the day, and
+// the occurrences overridden with a RECURRENCE-ID are replaced. Events that
+// cannot be read, e.g. with a time zone unknown to Go, are skipped and
+// returned as errors beside the others.
+func parseICS(content string) ([]icsEvent, []error) {
+	var (
+		events  []icsEvent
+		skipped []error
+		event   *icsEvent
+		allDay  bool
+		dur     time.Duration
+		depth   int   // of the components nested in the event, e.g. VALARM
+		err     error // the first error of the event
+	)
+
+	for i, line := range unfoldICS(content) {
+		name, params, value := splitICSLine(line)
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			event, allDay, dur, depth, err = &icsEvent{}, false, 0, 0, nil
+		case event == nil:
+			continue
+		case name == "BEGIN":
+			depth++
+		case name == "END" && depth > 0:
+			depth--
+		case depth > 0, err != nil && !(name == "END" && value == "VEVENT"):
+			// the SUMMARY or DURATION of an alarm is not the event's
+			continue
+		case name == "END" && value == "VEVENT":
+			switch {
+			case err != nil:
+				skipped = append(skipped, fmt.Errorf("skipped event %q: %w", event.Summary, err))
+			case !allDay && !event.Start.IsZero():
+				if event.End.IsZero() {
+					event.End = event.Start.Add(dur)
+				}
+				events = append(events, *event)
+			}
+			event = nil
+		case name == "SUMMARY":
+			event.Summary = unescapeICS(value)
+		case name == "UID":
+			event.uid = value
+		case name == "STATUS":
+			event.cancelled = strings.EqualFold(value, "CANCELLED")
+		case name == "RECURRENCE-ID":
+			if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+				continue
+			}
+			t, terr := parseICSTime(value, params["TZID"])
+			if terr != nil {
+				err = fmt.Errorf("line %d: %w", i+1, terr)
+				continue
+			}
+			event.recurrenceID = t
+		case name == "DTSTART", name == "DTEND":
+			if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+				allDay = true
+				continue
+			}
+			t, terr := parseICSTime(value, params["TZID"])
+			if terr != nil {
+				err = fmt.Errorf("line %d: %w", i+1, terr)
+				continue
+			}
+			// kept in its own time zone for the recurrences to follow its
+			// wall clock, converted to local time once expanded
+			if name == "DTSTART" {
+				event.Start = t
+			} else {
+				event.End = t
+			}
+		case name == "DURATION":
+			d, derr := parseICSDuration(value)
+			if derr != nil {
+				err = fmt.Errorf("line %d: %w", i+1, derr)
+				continue
+			}
+			dur = d
+		case name == "RRULE":
+			rule, rerr := parseICSRule(value)
+			if rerr != nil {
+				err = fmt.Errorf("line %d: %w", i+1, rerr)
+				continue
+			}
+			event.rule = rule
+		case name == "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				if params["VALUE"] == "DATE" || len(v) == len("20060102") {
+					continue
+				}
+				t, terr := parseICSTime(v, params["TZID"])
+				if terr != nil {
+					err = fmt.Errorf("line %d: %w", i+1, terr)
+					break
+				}
+				event.exdates = append(event.exdates, t)
+			}
+		}
+	}
+
+	return applyOverrides(events), skipped
+}
+
+// applyOverrides excludes the occurrences of the recurring events that are
+// overridden by an event with the same UID and a RECURRENCE-ID, and drops
+// the cancelled events, overrides included.
+func applyOverrides(events []icsEvent) []icsEvent {
+	masters := map[string]int{}
+	for i, e := range events {
+		if e.rule != nil && e.recurrenceID.IsZero() && e.uid != "" {
+			masters[e.uid] = i
+		}
+	}
+	for _, e := range events {
+		if i, ok := masters[e.uid]; ok && !e.recurrenceID.IsZero() {
+			events[i].exdates = append(events[i].exdates, e.recurrenceID)
+		}
+	}
+
+	kept := events[:0]
+	for _, e := range events {
+		if !e.cancelled {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// occurrences returns the occurrences of the event that overlap from and
+// to, in local time. An unsupported recurrence rule only yields the first
+// occurrence.
+func (e icsEvent) occurrences(from, to time.Time) []icsEvent {
+	length := e.End.Sub(e.Start)
+
+	var found []icsEvent
+	add := func(start time.Time) {
+		for _, ex := range e.exdates {
+			if ex.Equal(start) {
+				return
+			}
+		}
+		end := start.Add(length)
+		if start.Before(to) && end.After(from) {
+			found = append(found, icsEvent{Summary: e.Summary, Start: start.Local(), End: end.Local()})
+		}
+	}
+
+	if e.rule == nil {
+		add(e.Start)
+		return found
+	}
+
+	r := e.rule
+	y, m, d := e.Start.Date()
+	h, minute, sec := e.Start.Clock()
+	loc := e.Start.Location()
+
+	// the days of a period, as offsets from the first day of the period
+	days, period := []int{0}, 1
+	if r.Freq == "WEEKLY" {
+		period = 7
+		if len(r.ByDay) > 0 {
+			// weeks start on monday
+			monday := (int(e.Start.Weekday()) + 6) % 7
+			d -= monday
+			days = days[:0]
+			for _, wd := range r.ByDay {
+				days = append(days, (int(wd)+6)%7)
+			}
+			sort.Ints(days)
+		}
+	}
+
+	count := 0
+	for p := 0; ; p += r.Interval * period {
+		for _, offset := range days {
+			start := time.Date(y, m, d+p+offset, h, minute, sec, 0, loc)
+			switch {
+			case start.Before(e.Start):
+				continue
+			case !r.Until.IsZero() && start.After(r.Until),
+				r.Count > 0 && count >= r.Count,
+				!start.Before(to):
+				return found
+			}
+			count++
+			add(start)
+		}
+	}
+}
+
+// parseICSRule parses the RRULE value of a DAILY or WEEKLY recurrence, e.g.
+// FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20240630T000000Z. Other rules
+// are not supported and return nil.
+func parseICSRule(value string) (*icsRule, error) {
+	rule := &icsRule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, v, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(v)
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(v)
+			if err == nil && rule.Interval < 1 {
+				err = fmt.Errorf("invalid interval %q", v)
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(v)
+		case "UNTIL":
+			if len(v) == len("20060102") {
+				// an inclusive date, any time of that day
+				rule.Until, err = time.ParseInLocation("20060102", v, time.Local)
+				rule.Until = rule.Until.Add(24*time.Hour - time.Second)
+			} else {
+				rule.Until, err = parseICSTime(v, "")
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(v, ",") {
+				wd, ok := icsWeekdays[strings.ToUpper(day)]
+				if !ok {
+					// e.g. 1MO, the first monday of a month
+					return nil, nil
+				}
+				rule.ByDay = append(rule.ByDay, wd)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid RRULE %q: %w", value, err)
+		}
+	}
+
+	if rule.Freq != "DAILY" && rule.Freq != "WEEKLY" || rule.Freq == "DAILY" && len(rule.ByDay) > 0 {
+		return nil, nil
+	}
+	return rule, nil
+}
+
+// calendarEvents reads the events of the ics files, or of the ics_files of
+// the config when none are given, and returns their occurrences that
+// overlap from and to sorted by start. Events that cannot be read are
+// reported and skipped.
+func calendarEvents(from, to time.Time, files ...string) ([]icsEvent, error) {
+	if len(files) == 0 {
+		files = conf.QueryStrings("ics_files")
+	}
+
+	var events []icsEvent
+	for _, file := range files {
+		content, err := Read(expandHome(file))
+		if err != nil {
+			return nil, err
+		}
+		parsed, skipped := parseICS(content)
+		for _, err := range skipped {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+		}
+		for _, event := range parsed {
+			events = append(events, event.occurrences(from, to)...)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+
+	return events, nil
+}
+
+// nextEvent returns the first of the sorted events starting after now.
+func nextEvent(events []icsEvent, now time.Time) (icsEvent, bool) {
+	for _, event := range events {
+		if event.Start.After(now) {
+			return event, true
+		}
+	}
+	return icsEvent{}, false
+}
+
+// unfoldICS joins the lines continued with a leading space or tab.
+func unfoldICS(content string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitICSLine splits NAME;PARAM=VALUE:value into its parts.
+func splitICSLine(line string) (string, map[string]string, string) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	params := map[string]string{}
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+
+	return strings.ToUpper(parts[0]), params, line[colon+1:]
+}
+
+// parseICSTime parses a date-time in UTC (20060102T150405Z), in the tzid
+// time zone, or in local time when it has neither.
+func parseICSTime(value, tzid string) (time.Time, error) {
+	const layout = "20060102T150405"
+
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(layout+"Z", value)
+	}
+
+	loc := time.Local
+	if tzid != "" {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.ParseInLocation(layout, value, loc)
+}
+
+// parseICSDuration parses a duration such as PT1H30M or P1D.
+func parseICSDuration(value string) (time.Duration, error) {
+	m := icsDuration.FindStringSubmatch(value)
+	if m == nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(n) * unit
+	}
+
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+func unescapeICS(value string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
@@ -0,0 +1,196 @@
+package pomo
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// icsCalendar wraps the events in a calendar, one property per line.
+func icsCalendar(events ...string) string {
+	var sb strings.Builder
+	sb.WriteString("BEGIN:VCALENDAR\r\n")
+	for _, event := range events {
+		sb.WriteString("BEGIN:VEVENT\r\n" + event + "END:VEVENT\r\n")
+	}
+	sb.WriteString("END:VCALENDAR\r\n")
+	return sb.String()
+}
+
+func TestICSRecurrences(t *testing.T) {
+	loc := lisbon(t)
+	prevLocal := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = prevLocal })
+
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, 3, day, hour, min, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name  string
+		event string
+		day   int // of March 2024
+		want  []time.Time
+	}{
+		{
+			name:  "daily",
+			event: "SUMMARY:standup\r\nDTSTART;TZID=Europe/Lisbon:20240301T093000\r\nDTEND;TZID=Europe/Lisbon:20240301T094500\r\nRRULE:FREQ=DAILY\r\n",
+			day:   12,
+			want:  []time.Time{at(12, 9, 30)},
+		},
+		{
+			name:  "daily across the DST change keeps the wall clock",
+			event: "SUMMARY:standup\r\nDTSTART;TZID=Europe/Lisbon:20240301T093000\r\nDTEND;TZID=Europe/Lisbon:20240301T094500\r\nRRULE:FREQ=DAILY\r\n",
+			day:   31,
+			want:  []time.Time{at(31, 9, 30)},
+		},
+		{
+			name:  "excluded",
+			event: "SUMMARY:standup\r\nDTSTART;TZID=Europe/Lisbon:20240301T093000\r\nDTEND;TZID=Europe/Lisbon:20240301T094500\r\nRRULE:FREQ=DAILY\r\nEXDATE;TZID=Europe/Lisbon:20240311T093000,20240312T093000\r\n",
+			day:   12,
+		},
+		{
+			name:  "count",
+			event: "SUMMARY:standup\r\nDTSTART;TZID=Europe/Lisbon:20240301T093000\r\nDURATION:PT15M\r\nRRULE:FREQ=DAILY;COUNT=5\r\n",
+			day:   6,
+		},
+		{
+			name:  "until",
+			event: "SUMMARY:standup\r\nDTSTART;TZID=Europe/Lisbon:20240301T093000\r\nDURATION:PT15M\r\nRRULE:FREQ=DAILY;UNTIL=20240305T235959Z\r\n",
+			day:   5,
+			want:  []time.Time{at(5, 9, 30)},
+		},
+		{
+			name:  "weekly on its own weekday",
+			event: "SUMMARY:review\r\nDTSTART:20240301T140000Z\r\nDTEND:20240301T150000Z\r\nRRULE:FREQ=WEEKLY\r\n",
+			day:   15,
+			want:  []time.Time{at(15, 14, 0)},
+		},
+		{
+			name:  "weekly on another day",
+			event: "SUMMARY:review\r\nDTSTART:20240301T140000Z\r\nDTEND:20240301T150000Z\r\nRRULE:FREQ=WEEKLY\r\n",
+			day:   14,
+		},
+		{
+			name:  "every other week by day",
+			event: "SUMMARY:sync\r\nDTSTART;TZID=Europe/Lisbon:20240306T110000\r\nDTEND;TZID=Europe/Lisbon:20240306T113000\r\nRRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE\r\n",
+			day:   18,
+			want:  []time.Time{at(18, 11, 0)},
+		},
+		{
+			name:  "every other week skips a week",
+			event: "SUMMARY:sync\r\nDTSTART;TZID=Europe/Lisbon:20240306T110000\r\nDTEND;TZID=Europe/Lisbon:20240306T113000\r\nRRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE\r\n",
+			day:   11,
+		},
+		{
+			name:  "unsupported rule only has its first occurrence",
+			event: "SUMMARY:board\r\nDTSTART;TZID=Europe/Lisbon:20240301T100000\r\nDURATION:PT1H\r\nRRULE:FREQ=MONTHLY\r\n",
+			day:   1,
+			want:  []time.Time{at(1, 10, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, skipped := parseICS(icsCalendar(tt.event))
+			if len(skipped) > 0 {
+				t.Fatalf("parseICS() skipped %v", skipped)
+			}
+
+			from := at(tt.day, 0, 0)
+			var got []time.Time
+			for _, e := range events {
+				for _, o := range e.occurrences(from, from.AddDate(0, 0, 1)) {
+					got = append(got, o.Start)
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("occurrences on %s = %v, want %v", from.Format("2006-01-02"), got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("occurrence %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestICSUnknownTimeZone(t *testing.T) {
+	content := icsCalendar(
+		"SUMMARY:outlook\r\nDTSTART;TZID=W. Europe Standard Time:20240301T100000\r\nDURATION:PT1H\r\n",
+		"SUMMARY:other\r\nDTSTART:20240301T120000Z\r\nDURATION:PT1H\r\n",
+	)
+
+	events, skipped := parseICS(content)
+	if len(skipped) != 1 {
+		t.Errorf("skipped = %v, want the outlook event", skipped)
+	}
+	if len(events) != 1 || events[0].Summary != "other" {
+		t.Errorf("events = %+v, want only the other event", events)
+	}
+}
+
+func TestICSAlarm(t *testing.T) {
+	content := icsCalendar(
+		"SUMMARY:review\r\nDTSTART:20240301T140000Z\r\n" +
+			"BEGIN:VALARM\r\nACTION:DISPLAY\r\nSUMMARY:Alarm notification\r\nTRIGGER:-PT10M\r\nDURATION:PT5M\r\nREPEAT:2\r\nEND:VALARM\r\n" +
+			"DURATION:PT1H\r\n",
+	)
+
+	events, skipped := parseICS(content)
+	if len(skipped) > 0 {
+		t.Fatalf("parseICS() skipped %v", skipped)
+	}
+	if len(events) != 1 {
+		t.Fatalf("events = %+v, want one", events)
+	}
+	if events[0].Summary != "review" {
+		t.Errorf("summary = %q, want review", events[0].Summary)
+	}
+	if d := events[0].End.Sub(events[0].Start); d != time.Hour {
+		t.Errorf("length = %v, want 1h", d)
+	}
+}
+
+func TestICSOverrides(t *testing.T) {
+	standup := "UID:standup@example.com\r\nSUMMARY:standup\r\nDTSTART:20240304T093000Z\r\nDURATION:PT15M\r\nRRULE:FREQ=DAILY\r\n"
+	content := icsCalendar(
+		standup,
+		// moved to the afternoon on the 5th
+		"UID:standup@example.com\r\nRECURRENCE-ID:20240305T093000Z\r\nSUMMARY:standup\r\nDTSTART:20240305T150000Z\r\nDURATION:PT15M\r\n",
+		// cancelled on the 6th
+		"UID:standup@example.com\r\nRECURRENCE-ID:20240306T093000Z\r\nSTATUS:CANCELLED\r\nSUMMARY:standup\r\nDTSTART:20240306T093000Z\r\nDURATION:PT15M\r\n",
+		"UID:party@example.com\r\nSTATUS:CANCELLED\r\nSUMMARY:party\r\nDTSTART:20240305T180000Z\r\nDURATION:PT2H\r\n",
+	)
+
+	events, skipped := parseICS(content)
+	if len(skipped) > 0 {
+		t.Fatalf("parseICS() skipped %v", skipped)
+	}
+
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	var got []time.Time
+	for _, e := range events {
+		for _, o := range e.occurrences(from, from.AddDate(0, 0, 3)) {
+			got = append(got, o.Start.UTC())
+		}
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].Before(got[j]) })
+
+	want := []time.Time{
+		time.Date(2024, 3, 4, 9, 30, 0, 0, time.UTC),
+		time.Date(2024, 3, 5, 15, 0, 0, 0, time.UTC),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("occurrences = %v, want %v", got, want)
+	}
+	for i := range got {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("occurrence %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package pomo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// malformedLine is a line of the session log that could not be scanned.
+type malformedLine struct {
+	Line int // 1-based line number
+	Text string
+	Err  error
+}
+
+// indexEntry is a scanned line of the session log.
+type indexEntry struct {
+	line    int // 1-based line number
+	text    string
+	session Session
+	err     error
+}
+
+// dayTotals is the work and break time of a day.
+type dayTotals struct {
+	to    time.Time
+	work  time.Duration
+	pause time.Duration
+}
+
+// sessionIndex keeps the session log parsed between reads. The file is
+// polled by its modification time and size, and when it grew only the bytes
+// after the last complete line are read and scanned. Sessions are saved and
+// deleted by replacing the file, so a different file, a shorter one or a
+// last line that is not in place anymore is read again from the start. As
+// modification times are coarse, a file modified within a second of the
+// last read is read again so a write right after it is not missed. Daily
+// totals are cached until a session of their day changes. Malformed lines
+// are skipped and kept aside to be reported.
+type sessionIndex struct {
+	mu sync.Mutex
+
+	path    string
+	file    os.FileInfo
+	modTime time.Time
+	size    int64
+	read    time.Time // when the file was last read
+
+	offset int64  // end of the last complete line
+	last   string // the last complete line with its newline
+	lines  int    // complete lines read
+
+	entries  []indexEntry // of the complete lines
+	list     []Session    // of the complete lines that scanned
+	tail     *indexEntry  // a last line without a newline, read again every time
+	reported map[string]bool
+
+	days map[time.Time]dayTotals // by day start
+}
+
+// index is the session log shared by every reader of the process.
+var index = &sessionIndex{}
+
+// refresh brings the index up to date with the session log.
+func (idx *sessionIndex) refresh() error {
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if path == idx.path && info.ModTime().Equal(idx.modTime) && info.Size() == idx.size &&
+		idx.read.Sub(idx.modTime) > time.Second {
+		return nil
+	}
+
+	read := time.Now()
+
+	var data []byte
+	appended := path == idx.path && idx.file != nil && os.SameFile(info, idx.file) && info.Size() >= idx.offset
+	if appended {
+		if data, appended, err = readAppended(f, idx.offset, idx.last); err != nil {
+			return err
+		}
+	}
+
+	// the scanned lines that may be in the file still
+	reuse := map[string]indexEntry{}
+	if idx.tail != nil {
+		reuse[idx.tail.text] = *idx.tail
+	}
+	if !appended {
+		if path != idx.path {
+			idx.days = nil
+		} else {
+			for _, entry := range idx.entries {
+				reuse[entry.text] = entry
+			}
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if data, err = io.ReadAll(f); err != nil {
+			return err
+		}
+		idx.offset, idx.last, idx.lines, idx.entries, idx.list = 0, "", 0, nil, nil
+	}
+
+	var changed []Session
+	seen := map[string]bool{}
+	scan := func(line int, text string) indexEntry {
+		seen[text] = true
+		if entry, ok := reuse[text]; ok {
+			entry.line = line
+			return entry
+		}
+		entry := indexEntry{line: line, text: text}
+		entry.err = entry.session.Scan(text)
+		if entry.err == nil {
+			changed = append(changed, entry.session)
+		}
+		return entry
+	}
+
+	pieces := strings.Split(string(data), "\n")
+	for _, text := range pieces[:len(pieces)-1] {
+		idx.lines++
+		idx.offset += int64(len(text)) + 1
+		idx.last = text + "\n"
+		if text = strings.TrimSuffix(text, "\r"); text == "" {
+			continue
+		}
+		entry := scan(idx.lines, text)
+		idx.entries = append(idx.entries, entry)
+		if entry.err == nil {
+			idx.list = append(idx.list, entry.session)
+		}
+	}
+	idx.tail = nil
+	if text := strings.TrimSuffix(pieces[len(pieces)-1], "\r"); text != "" {
+		tail := scan(idx.lines+1, text)
+		idx.tail = &tail
+	}
+
+	// sessions that were rewritten or deleted
+	for text, entry := range reuse {
+		if !seen[text] && entry.err == nil {
+			changed = append(changed, entry.session)
+		}
+	}
+	idx.invalidate(changed)
+
+	idx.path, idx.file, idx.modTime, idx.size, idx.read = path, info, info.ModTime(), info.Size(), read
+
+	return nil
+}
+
+// readAppended reads f from offset, and returns false when the line ending
+// at offset is not last anymore, i.e. the file was rewritten.
+func readAppended(f *os.File, offset int64, last string) ([]byte, bool, error) {
+	if _, err := f.Seek(offset-int64(len(last)), io.SeekStart); err != nil {
+		return nil, false, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, false, err
+	}
+	if !bytes.HasPrefix(data, []byte(last)) {
+		return nil, false, nil
+	}
+	return data[len(last):], true, nil
+}
+
+// scanned returns the entries of the log, the last line included.
+func (idx *sessionIndex) scanned() []indexEntry {
+	if idx.tail == nil {
+		return idx.entries
+	}
+	return append(idx.entries[:len(idx.entries):len(idx.entries)], *idx.tail)
+}
+
+// sessions returns the sessions of the log, in the order of the file. The
+// slice is shared unless the last line has no newline.
+func (idx *sessionIndex) sessions() []Session {
+	if idx.tail == nil || idx.tail.err != nil {
+		return idx.list
+	}
+	return append(idx.list[:len(idx.list):len(idx.list)], idx.tail.session)
+}
+
+// invalidate drops the cached totals of the days the sessions overlap.
+func (idx *sessionIndex) invalidate(changed []Session) {
+	for from, totals := range idx.days {
+		for _, s := range changed {
+			if s.StartTime.Before(totals.to) && sessionEnd(s).After(from) {
+				delete(idx.days, from)
+				break
+			}
+		}
+	}
+}
+
+// Sessions returns the sessions of the log, in the order of the file.
+func (idx *sessionIndex) Sessions() ([]Session, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if err := idx.refresh(); err != nil {
+		return nil, err
+	}
+	return append([]Session(nil), idx.sessions()...), nil
+}
+
+// Malformed returns the lines of the log that were skipped.
+func (idx *sessionIndex) Malformed() []malformedLine {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.malformed()
+}
+
+func (idx *sessionIndex) malformed() []malformedLine {
+	var malformed []malformedLine
+	for _, entry := range idx.scanned() {
+		if entry.err != nil {
+			malformed = append(malformed, malformedLine{Line: entry.line, Text: entry.text, Err: entry.err})
+		}
+	}
+	return malformed
+}
+
+// DaySummary returns the work and break time between from and to, the
+// bounds of a day. Days that are over and have no running session are
+// cached.
+func (idx *sessionIndex) DaySummary(from, to time.Time) (time.Duration, time.Duration, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if err := idx.refresh(); err != nil {
+		return 0, 0, err
+	}
+
+	if totals, ok := idx.days[from]; ok && totals.to.Equal(to) {
+		return totals.work, totals.pause, nil
+	}
+
+	day := filterSessions(idx.sessions(), from, to)
+	typeDurations, _ := summarizeSessions(day, from, to)
+	totals := dayTotals{to: to, work: typeDurations[WorkSession], pause: typeDurations[BreakSession]}
+
+	cacheable := !to.After(clock.Now())
+	for _, s := range day {
+		if s.isRunning() {
+			cacheable = false
+		}
+	}
+	if cacheable {
+		if idx.days == nil {
+			idx.days = map[time.Time]dayTotals{}
+		}
+		idx.days[from] = totals
+	}
+
+	return totals.work, totals.pause, nil
+}
+
+// report prints the malformed lines that were not reported yet.
+func (idx *sessionIndex) report() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.reported == nil {
+		idx.reported = map[string]bool{}
+	}
+	for _, m := range idx.malformed() {
+		if idx.reported[m.Text] {
+			continue
+		}
+		idx.reported[m.Text] = true
+		fmt.Fprintf(os.Stderr, "%s:%d: skipped malformed session: %v\n", SESSION_FILENAME, m.Line, m.Err)
+	}
+}
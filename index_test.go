@@ -0,0 +1,123 @@
+package pomo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// indexSessions returns the sessions of the index, failing the test on
+// errors.
+func indexSessions(t *testing.T) []Session {
+	t.Helper()
+	sessions, err := index.Sessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sessions
+}
+
+func TestIndexAppend(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupTest(t, now, nil)
+
+	first := Session{ID: uuid.New(), StartTime: now.Add(-time.Hour), EndTime: now.Add(-35 * time.Minute), Duration: 25 * time.Minute, Type: WorkSession}
+	writeSessions(t, first)
+	if got := indexSessions(t); len(got) != 1 {
+		t.Fatalf("sessions = %+v, want the first session", got)
+	}
+	offset := index.offset
+
+	second := Session{ID: uuid.New(), StartTime: now, Duration: 25 * time.Minute, Type: WorkSession}
+	path := filepath.Join(conf.DirPath(), SESSION_FILENAME)
+	if err := InsertLine(path, second.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := indexSessions(t)
+	if len(got) != 2 || got[0].ID != first.ID || got[1].ID != second.ID {
+		t.Fatalf("sessions = %+v, want the first and the second session", got)
+	}
+	if want := offset + int64(len(second.String())+1); index.offset != want || index.lines != 2 {
+		t.Errorf("read up to byte %d on line %d, want %d on line 2", index.offset, index.lines, want)
+	}
+}
+
+func TestIndexRewrite(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	now := day.Add(34 * time.Hour)
+	setupTest(t, now, nil)
+
+	work := Session{ID: uuid.New(), StartTime: day.Add(10 * time.Hour), EndTime: day.Add(10*time.Hour + 25*time.Minute), Duration: 25 * time.Minute, Type: WorkSession}
+	pause := Session{ID: uuid.New(), StartTime: day.Add(11 * time.Hour), EndTime: day.Add(11*time.Hour + 5*time.Minute), Duration: 5 * time.Minute, Type: BreakSession}
+	running := Session{ID: uuid.New(), StartTime: now, Duration: 25 * time.Minute, Type: WorkSession}
+	writeSessions(t, work, pause, running)
+
+	summary := func() time.Duration {
+		t.Helper()
+		total, _, err := index.DaySummary(day, day.Add(24*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return total
+	}
+	if got := summary(); got != 25*time.Minute {
+		t.Fatalf("work of the day = %s, want 25m", got)
+	}
+
+	// a save in the middle of the log drops the cached total of its day
+	work.EndTime = work.StartTime.Add(40 * time.Minute)
+	if err := work.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := summary(); got != 40*time.Minute {
+		t.Errorf("work of the day after a save = %s, want 40m", got)
+	}
+
+	if err := work.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	got := indexSessions(t)
+	if len(got) != 2 || got[0].ID != pause.ID || got[1].ID != running.ID {
+		t.Errorf("sessions after a delete = %+v, want the break and the running session", got)
+	}
+	if got := summary(); got != 0 {
+		t.Errorf("work of the day after a delete = %s, want none", got)
+	}
+}
+
+func TestIndexMalformed(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupTest(t, now, nil)
+
+	session := Session{ID: uuid.New(), StartTime: now, Duration: 25 * time.Minute, Type: WorkSession}
+	path := filepath.Join(conf.DirPath(), SESSION_FILENAME)
+	content := "not a session\n" + session.String() + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func() error {
+		sessions, err := ListSessions()
+		if err == nil && (len(sessions) != 1 || sessions[0].ID != session.ID) {
+			t.Errorf("sessions = %+v, want the valid session only", sessions)
+		}
+		return err
+	}
+
+	out := captureStderr(t, list)
+	if !strings.Contains(out, SESSION_FILENAME+":1:") {
+		t.Errorf("stderr = %q, want the malformed line 1 reported", out)
+	}
+	if out := captureStderr(t, list); out != "" {
+		t.Errorf("stderr on the second read = %q, want nothing", out)
+	}
+
+	if malformed := index.Malformed(); len(malformed) != 1 || malformed[0].Text != "not a session" {
+		t.Errorf("Malformed() = %+v, want the first line", malformed)
+	}
+}
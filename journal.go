@@ -0,0 +1,171 @@
+package pomo
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Journal formats, see the journal_format config key.
+const (
+	JournalMarkdown = "markdown"
+	JournalOrg      = "org"
+)
+
+func init() {
+	hooks = append(hooks, journalHook)
+}
+
+// journalHook appends every finished work session to the journal_file.
+func journalHook(event string, s Session) error {
+	path := conf.QueryStringOr("journal_file", "")
+	if event != EventStop || s.Type != WorkSession || path == "" {
+		return nil
+	}
+
+	path = expandHome(path)
+
+	var content string
+	if Exists(path) {
+		var err error
+		if content, err = Read(path); err != nil {
+			return err
+		}
+	}
+
+	return Write(path, journalAppend(content, s, journalFormat(path)))
+}
+
+// RebuildJournal regenerates the journal from the work sessions that
+// started between from and to. It is written to output, the journal_file
+// when empty. As the sessions outside of the range are left out, an
+// existing file is only overwritten with force.
+func RebuildJournal(from, to time.Time, output string, force bool) error {
+	path := output
+	if path == "" {
+		path = conf.QueryStringOr("journal_file", "")
+	}
+	if path == "" {
+		return fmt.Errorf("journal_file is not set in the config")
+	}
+	path = expandHome(path)
+
+	if Exists(path) && !force {
+		return fmt.Errorf("%s already exists, use --force to overwrite it or --output to write elsewhere", path)
+	}
+
+	sessions, err := ListSessions()
+	if err != nil {
+		return err
+	}
+
+	format := journalFormat(path)
+
+	var content string
+	for _, s := range sessions {
+		if s.Type != WorkSession || s.isRunning() || s.StartTime.Before(from) || !s.StartTime.Before(to) {
+			continue
+		}
+		content = journalAppend(content, s, format)
+	}
+
+	return Write(path, content)
+}
+
+// journalFormat returns the journal_format, guessed from the file extension
+// when it is not set.
+func journalFormat(path string) string {
+	if format := conf.QueryStringOr("journal_format", ""); format != "" {
+		return format
+	}
+	if filepath.Ext(path) == ".org" {
+		return JournalOrg
+	}
+	return JournalMarkdown
+}
+
+// journalAppend adds the session to the journal content.
+func journalAppend(content string, s Session, format string) string {
+	if format == JournalOrg {
+		return orgAppend(content, s)
+	}
+	return markdownAppend(content, s)
+}
+
+// markdownAppend adds a list item for the session under a heading for its
+// day:
+//
+//	## 2024-01-02
+//
+//	- 10:00–10:25 (25m) write docs: first draft
+func markdownAppend(content string, s Session) string {
+	heading := "## " + s.StartTime.Format("2006-01-02")
+
+	var sb strings.Builder
+	sb.WriteString(content)
+	if !strings.Contains("\n"+content, "\n"+heading+"\n") {
+		if content != "" {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(heading + "\n\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("- %s–%s (%s) %s",
+		s.StartTime.Format("15:04"),
+		s.EndTime.Format("15:04"),
+		formatDurationHm(s.EndTime.Sub(s.StartTime)),
+		journalTask(s),
+	))
+	if s.Note != "" {
+		sb.WriteString(": " + s.Note)
+	}
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// orgAppend adds a CLOCK entry for the session, followed by its note, under
+// a top level heading named after its task. The heading is created when
+// needed and entries are kept newest first.
+func orgAppend(content string, s Session) string {
+	const layout = "2006-01-02 Mon 15:04"
+
+	heading := "* " + journalTask(s)
+	d := s.EndTime.Sub(s.StartTime)
+	entry := []string{fmt.Sprintf("CLOCK: [%s]--[%s] => %2d:%02d",
+		s.StartTime.Format(layout),
+		s.EndTime.Format(layout),
+		int(d.Hours()),
+		int(d.Minutes())%60,
+	)}
+	if s.Note != "" {
+		entry = append(entry, "- "+s.Note)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	for i, line := range lines {
+		if line == heading {
+			lines = append(lines[:i+1], append(entry, lines[i+1:]...)...)
+			return strings.Join(lines, "\n") + "\n"
+		}
+	}
+
+	lines = append(lines, heading)
+	lines = append(lines, entry...)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func journalTask(s Session) string {
+	if s.Task != "" {
+		return s.Task
+	}
+	if s.File != "" {
+		return filepath.Base(s.File)
+	}
+	return string(s.Type)
+}
@@ -0,0 +1,55 @@
+package pomo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRebuildJournal(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	journal := filepath.Join(t.TempDir(), "journal.md")
+	setupTest(t, start.Add(24*time.Hour), map[string]any{"journal_file": journal})
+	writeSessions(t,
+		Session{ID: uuid.New(), StartTime: start, EndTime: start.Add(25 * time.Minute), Duration: 25 * time.Minute, Type: WorkSession, Task: "docs"},
+	)
+
+	from, to := dayBounds(start)
+	want := "## 2024-01-02\n\n- 10:00–10:25 (25m) docs\n"
+
+	if err := RebuildJournal(from, to, "", false); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := Read(journal); got != want {
+		t.Errorf("journal = %q, want %q", got, want)
+	}
+
+	// the existing journal is kept without force
+	if err := os.WriteFile(journal, []byte("kept\n"), DefaultPerms); err != nil {
+		t.Fatal(err)
+	}
+	if err := RebuildJournal(from, to, "", false); err == nil {
+		t.Error("RebuildJournal() overwrote the journal without force")
+	}
+	if got, _ := Read(journal); got != "kept\n" {
+		t.Errorf("journal = %q, want it untouched", got)
+	}
+
+	output := filepath.Join(t.TempDir(), "rebuilt.md")
+	if err := RebuildJournal(from, to, output, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := Read(output); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	if err := RebuildJournal(from, to, "", true); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := Read(journal); got != want {
+		t.Errorf("journal with force = %q, want %q", got, want)
+	}
+}
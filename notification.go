@@ -3,12 +3,26 @@ package pomo
 import (
 	"fmt"
 	"os/exec"
+	"strings"
 )
 
 const appID = "Microsoft.WSL"
 
-func sendNotification(title, message string) error {
-	psScript := fmt.Sprintf(`New-BurntToastNotification -Text "%s", "%s" -AppId %s`, title, message, appID)
+// Notification urgency levels, see the alert_urgency config key.
+const (
+	UrgencyLow      = "low"
+	UrgencyNormal   = "normal"
+	UrgencyCritical = "critical"
+)
+
+func sendNotification(title, message, urgency string) error {
+	psScript := fmt.Sprintf(`New-BurntToastNotification -Text %s, %s -AppId %s`, psQuote(title), psQuote(message), appID)
+	switch urgency {
+	case UrgencyLow:
+		psScript += " -Silent"
+	case UrgencyCritical:
+		psScript += " -Urgent"
+	}
 	cmd := exec.Command("pwsh.exe", "-Command", psScript)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("notification error: %v, output: %s", err, string(output))
@@ -16,11 +30,21 @@ func sendNotification(title, message string) error {
 	return nil
 }
 
-func playAlert() error {
+// playAlert beeps through PowerShell, shorter and lower for the warning.
+func playAlert(event string) error {
 	psScript := `[Console]::Beep(800, 500)` // frequency: 800Hz, duration: 500ms
+	if event == EventWarning {
+		psScript = `[Console]::Beep(440, 150)`
+	}
 	cmd := exec.Command("pwsh.exe", "-Command", psScript)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("alert sound error: %v, output: %s", err, string(output))
 	}
 	return nil
 }
+
+// psQuote quotes s as a literal PowerShell string. PowerShell takes the
+// curly single quotes for quotes too, so they are doubled as well.
+func psQuote(s string) string {
+	return "'" + strings.NewReplacer("'", "''", "‘", "‘‘", "’", "’’", "‚", "‚‚", "‛", "‛‛").Replace(s) + "'"
+}
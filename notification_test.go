@@ -0,0 +1,19 @@
+package pomo
+
+import "testing"
+
+func TestPSQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Time's up", "'Time''s up'"},
+		{`"$(Remove-Item x)" and ` + "`n", `'"$(Remove-Item x)" and ` + "`n'"},
+		{"it’s done", "'it’’s done'"},
+	}
+
+	for _, tt := range tests {
+		if got := psQuote(tt.in); got != tt.want {
+			t.Errorf("psQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
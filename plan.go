@@ -0,0 +1,261 @@
+package pomo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// PlanEnd is the local time at which the planned day ends.
+	PlanEnd = "18:00"
+	// LongBreakAfter is the number of work sessions before a long break.
+	LongBreakAfter = 4
+)
+
+// calendarWarning returns a warning when the work session s would overlap
+// the next event of the ics_files, or an empty string. It is left to the
+// caller to show as the TUI owns the terminal while it runs.
+func calendarWarning(s Session) (string, error) {
+	if s.Type != WorkSession || s.Name != "" || len(conf.QueryStrings("ics_files")) == 0 {
+		return "", nil
+	}
+
+	events, err := calendarEvents(s.StartTime, s.plannedEnd())
+	if err != nil {
+		return "", err
+	}
+	next, ok := nextEvent(events, s.StartTime)
+	if !ok || !s.plannedEnd().After(next.Start) {
+		return "", nil
+	}
+	return fmt.Sprintf("[WARNING]: the session ends at %s, after %s starts at %s",
+		s.plannedEnd().Format("15:04"), next.Summary, next.Start.Format("15:04")), nil
+}
+
+// planBlock is a session laid out by the plan, or a calendar event when
+// Event is set.
+type planBlock struct {
+	Type  SessionType
+	Event string
+	Start time.Time
+	End   time.Time
+	Short bool // a work session cut short by the next event
+}
+
+// planConfig holds the session durations the plan is laid out with.
+type planConfig struct {
+	Work      time.Duration
+	Break     time.Duration
+	LongBreak time.Duration
+	Every     int // work sessions before a long break
+}
+
+// Plan prints the sessions that fit between now and plan_end around the
+// events of the calendar. With fit the running session is shortened to end
+// when the next event starts.
+func Plan(fit bool, files ...string) error {
+	cfg, err := currentPlanConfig()
+	if err != nil {
+		return err
+	}
+
+	now := clock.Now()
+	end, err := planEnd(now)
+	if err != nil {
+		return err
+	}
+
+	var events []icsEvent
+	if len(files) > 0 || len(conf.QueryStrings("ics_files")) > 0 {
+		from, to := dayBounds(now)
+		if events, err = calendarEvents(from, to, files...); err != nil {
+			return err
+		}
+	}
+
+	sessions, err := ListSessions()
+	if err != nil {
+		return err
+	}
+	today, err := filterTodaySessions(sessions)
+	if err != nil {
+		return err
+	}
+
+	var cycle int
+	for _, s := range today {
+		if s.Type == WorkSession && s.Name == "" {
+			cycle++
+		}
+	}
+
+	var session Session
+	if err := session.Get(); err != nil {
+		return err
+	}
+
+	from := now.Truncate(time.Minute)
+	if session.ID != uuid.Nil && session.isRunning() {
+		if session, err = fitRunning(session, events, fit); err != nil {
+			return err
+		}
+		if runningEnd := session.plannedEnd(); runningEnd.After(from) {
+			from = runningEnd
+		}
+		fmt.Printf("%s–%s  %-9s (running)\n",
+			session.StartTime.Format("15:04"), session.plannedEnd().Format("15:04"), session.Type)
+	}
+
+	if !from.Before(end) {
+		fmt.Printf("Nothing left to plan before %s\n", end.Format("15:04"))
+		return printPlannedTasks(today)
+	}
+
+	for _, b := range planDay(events, from, end, cfg, cycle) {
+		line := fmt.Sprintf("%s–%s  ", b.Start.Format("15:04"), b.End.Format("15:04"))
+		switch {
+		case b.Event != "":
+			line += "📅 " + b.Event
+		case b.Short:
+			line += fmt.Sprintf("%-9s %s (a full %s pomodoro would overlap the next event)",
+				b.Type, formatDurationHm(b.End.Sub(b.Start)), formatDurationHm(cfg.Work))
+		default:
+			line += fmt.Sprintf("%-9s %s", b.Type, formatDurationHm(b.End.Sub(b.Start)))
+		}
+		fmt.Println(line)
+	}
+
+	return printPlannedTasks(today)
+}
+
+// printPlannedTasks lists the tasks of today's plan with their progress.
+func printPlannedTasks(today []Session) error {
+	plans, err := readPlans()
+	if err != nil {
+		return err
+	}
+	tasks := plans[planDayKey(clock.Now())]
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	from, to := dayBounds(clock.Now())
+	actual := pomodorosPerTask(today, from, to)
+
+	fmt.Println("\nTasks")
+	for _, p := range tasks {
+		fmt.Printf("%5s  %s\n", fmt.Sprintf("%d/%d", actual[p.Task], p.Estimate), p.Task)
+	}
+	return nil
+}
+
+// fitRunning warns when the running work session ends after the next event
+// starts, and shortens it to end at the event with fit.
+func fitRunning(s Session, events []icsEvent, fit bool) (Session, error) {
+	event, ok := nextEvent(events, clock.Now())
+	if !ok || s.Type != WorkSession || !s.plannedEnd().After(event.Start) {
+		return s, nil
+	}
+
+	if !fit {
+		fmt.Printf("[WARNING]: the running session ends at %s, after %s starts at %s (use --fit to shorten it)\n",
+			s.plannedEnd().Format("15:04"), event.Summary, event.Start.Format("15:04"))
+		return s, nil
+	}
+
+	if err := s.Adjust(event.Start.Sub(s.plannedEnd())); err != nil {
+		return s, err
+	}
+	fmt.Printf("Shortened the running session to end at %s, before %s\n",
+		s.plannedEnd().Format("15:04"), event.Summary)
+	return s, nil
+}
+
+// planDay lays out work sessions and breaks in the free time between from
+// and to, around the events. cycle is the number of work sessions already
+// done today so long breaks carry on from them. Breaks are only planned
+// between two work sessions, and a free block too short for a full work
+// session is filled with a shorter one when it fits at least a break.
+func planDay(events []icsEvent, from, to time.Time, cfg planConfig, cycle int) []planBlock {
+	var blocks []planBlock
+
+	fill := func(start, end time.Time) {
+		for {
+			left := end.Sub(start)
+			if left <= 0 || (left < cfg.Work && left < cfg.Break) {
+				return
+			}
+
+			work := planBlock{Type: WorkSession, Start: start, End: start.Add(cfg.Work)}
+			if left < cfg.Work {
+				work.End, work.Short = end, true
+			}
+			blocks = append(blocks, work)
+			cycle++
+			start = work.End
+
+			pause := planBlock{Type: BreakSession, Start: start, End: start.Add(cfg.Break)}
+			if cfg.Every > 0 && cycle%cfg.Every == 0 {
+				pause.Type, pause.End = LongBreakSession, start.Add(cfg.LongBreak)
+			}
+			// a break right before an event is not planned, the event is one
+			if end.Sub(pause.End) < cfg.Break {
+				return
+			}
+			blocks = append(blocks, pause)
+			start = pause.End
+		}
+	}
+
+	start := from
+	for _, event := range events {
+		if !event.End.After(start) || !event.Start.Before(to) {
+			continue
+		}
+		if event.Start.After(start) {
+			fill(start, event.Start)
+		}
+		blocks = append(blocks, planBlock{Event: event.Summary, Start: event.Start, End: event.End})
+		if event.End.After(start) {
+			start = event.End
+		}
+	}
+	if start.Before(to) {
+		fill(start, to)
+	}
+
+	return blocks
+}
+
+func currentPlanConfig() (planConfig, error) {
+	cfg := planConfig{Every: conf.QueryInt("long_break_after", LongBreakAfter)}
+
+	for _, d := range []struct {
+		key, def string
+		dst      *time.Duration
+	}{
+		{"duration", Duration, &cfg.Work},
+		{"break", Break, &cfg.Break},
+		{"long_break", LongBreak, &cfg.LongBreak},
+	} {
+		v, err := time.ParseDuration(conf.QueryStringOr(d.key, d.def))
+		if err != nil || v <= 0 {
+			return cfg, fmt.Errorf("invalid %s in the config", d.key)
+		}
+		*d.dst = v
+	}
+
+	return cfg, nil
+}
+
+// planEnd returns the plan_end of the day of now.
+func planEnd(now time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", conf.QueryStringOr("plan_end", PlanEnd))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid plan_end in the config, it must be like 18:00")
+	}
+	y, m, d := now.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, now.Location()), nil
+}
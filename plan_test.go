@@ -0,0 +1,92 @@
+package pomo
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+	return captureOutput(t, &os.Stdout, fn)
+}
+
+// captureStderr returns what fn prints to stderr.
+func captureStderr(t *testing.T, fn func() error) string {
+	t.Helper()
+	return captureOutput(t, &os.Stderr, fn)
+}
+
+func captureOutput(t *testing.T, f **os.File, fn func() error) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := *f
+	*f = w
+	ferr := fn()
+	*f = prev
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ferr != nil {
+		t.Fatal(ferr)
+	}
+	return string(out)
+}
+
+func TestPlanEmptyLog(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupTest(t, now, map[string]any{"plan_end": "11:00"})
+	writeSessions(t)
+
+	out := captureStdout(t, func() error { return Plan(false) })
+
+	if strings.Contains(out, "(running)") {
+		t.Errorf("Plan() printed a running session for an empty log:\n%s", out)
+	}
+	if !strings.HasPrefix(out, "10:00–10:25  work") {
+		t.Errorf("Plan() = %q, want it to start with a work session at 10:00", out)
+	}
+}
+
+func TestCalendarWarning(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	ics := filepath.Join(t.TempDir(), "work.ics")
+	content := icsCalendar("SUMMARY:standup\r\nDTSTART:20240301T101500Z\r\nDURATION:PT15M\r\n")
+	if err := os.WriteFile(ics, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	setupTest(t, now, map[string]any{"ics_files": []string{ics}})
+
+	tests := []struct {
+		name     string
+		duration time.Duration
+		want     bool
+	}{
+		{"ends before the event", 10 * time.Minute, false},
+		{"overlaps the event", 25 * time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Session{StartTime: now, Duration: tt.duration, Type: WorkSession}
+			warning, err := calendarWarning(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Contains(warning, "standup"); got != tt.want {
+				t.Errorf("calendarWarning() = %q, want a warning %v", warning, tt.want)
+			}
+		})
+	}
+}
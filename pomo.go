@@ -3,8 +3,10 @@ package pomo
 import (
 	"fmt"
 	"log"
+	"path/filepath"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/urfave/cli/v2"
 )
 
@@ -42,12 +44,31 @@ var App = &cli.App{
 	Name:                 "pomo",
 	Usage:                "A pomodoro command line interface 🍅",
 	EnableBashCompletion: true,
+	Before:               before,
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
 			Name:    "ui",
 			Aliases: []string{"u"},
 			Usage:   "display interactive terminal UI",
 		},
+		&cli.StringFlag{
+			Name:    "task",
+			Aliases: []string{"t"},
+			Usage:   "task worked on during the session",
+		},
+		&cli.BoolFlag{
+			Name:    "pick",
+			Aliases: []string{"p"},
+			Usage:   "pick the task from the todo.txt file",
+		},
+		&cli.StringFlag{
+			Name:  "tw",
+			Usage: "taskwarrior task id to start and stop with the session",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "work until the given local time, e.g. 13:55",
+		},
 	},
 	Action: func(cCtx *cli.Context) error {
 		var arg string
@@ -59,6 +80,11 @@ var App = &cli.App{
 			}
 		}
 
+		until, err := untilFlag(cCtx.String("until"))
+		if err != nil {
+			return err
+		}
+
 		var durationStr string
 		if arg != "" {
 			durationStr = arg
@@ -74,6 +100,18 @@ var App = &cli.App{
 			return err
 		}
 
+		// pick before the running session is touched, a cancelled or
+		// invalid pick leaves it running
+		next := Session{Task: cCtx.String("task")}
+		if cCtx.Bool("pick") {
+			todo, err := PickTodo()
+			if err != nil {
+				return err
+			}
+			next.Task = todo.Text
+			next.Project = todo.Project()
+		}
+
 		var session Session
 		if err := session.Get(); err != nil {
 			return err
@@ -83,6 +121,9 @@ var App = &cli.App{
 			if session.Type == WorkSession {
 				ok := InputConfirm("[WARNING]: A session is already running, do you want to reset it?")
 				if ok {
+					if err := stopTaskwarrior(session); err != nil {
+						fmt.Printf("Failed to stop the taskwarrior task: %v\n", err)
+					}
 					if err := session.Delete(); err != nil {
 						return err
 					}
@@ -100,9 +141,26 @@ var App = &cli.App{
 			}
 		}
 
-		if err := session.Start(conf, duration, WorkSession); err != nil {
+		if next.Task == "" && !cCtx.Bool("pick") {
+			if task, ok := autoPlannedTask(); ok {
+				next.Task = task
+				fmt.Printf("Working on %q from today's plan\n", task)
+			}
+		}
+		if id := cCtx.String("tw"); id != "" {
+			if err := useTaskwarrior(&next, id); err != nil {
+				return err
+			}
+		}
+
+		if err := next.start(conf, duration, until, WorkSession); err != nil {
 			return err
 		}
+		if warning, err := calendarWarning(next); err != nil {
+			fmt.Printf("Failed to read the calendar: %v\n", err)
+		} else if warning != "" {
+			fmt.Println(warning)
+		}
 
 		if err := conf.Set("prefix", WorkPrefix); err != nil {
 			return err
@@ -124,6 +182,10 @@ var App = &cli.App{
 					Aliases: []string{"u"},
 					Usage:   "display interactive terminal UI",
 				},
+				&cli.StringFlag{
+					Name:  "until",
+					Usage: "break until the given local time, e.g. 13:55",
+				},
 			},
 			Action: func(cCtx *cli.Context) error {
 				var arg string
@@ -135,6 +197,11 @@ var App = &cli.App{
 					}
 				}
 
+				until, err := untilFlag(cCtx.String("until"))
+				if err != nil {
+					return err
+				}
+
 				var durationStr string
 				if arg != "" {
 					durationStr = arg
@@ -160,6 +227,9 @@ var App = &cli.App{
 					if session.Type == BreakSession {
 						ok := InputConfirm("[WARNING]: A session is already running, do you want to reset it?")
 						if ok {
+							if err := stopTaskwarrior(session); err != nil {
+								fmt.Printf("Failed to stop the taskwarrior task: %v\n", err)
+							}
 							if err := session.Delete(); err != nil {
 								return err
 							}
@@ -176,7 +246,8 @@ var App = &cli.App{
 					}
 				}
 
-				if err := session.Start(conf, duration, WorkSession); err != nil {
+				var next Session
+				if err := next.start(conf, duration, until, BreakSession); err != nil {
 					return err
 				}
 
@@ -192,9 +263,132 @@ var App = &cli.App{
 			},
 		},
 		{
-			Name:  "stop",
-			Usage: "stop the pomodoro countdown",
-			Action: func(_ *cli.Context) error {
+			Name:      "until",
+			Usage:     "count down to a local time, or to the next event of the calendar",
+			ArgsUsage: "[13:55]",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:    "break",
+					Aliases: []string{"b"},
+					Usage:   "start a break instead of a work session",
+				},
+				&cli.StringFlag{
+					Name:    "task",
+					Aliases: []string{"t"},
+					Usage:   "task worked on during the session",
+				},
+				&cli.StringSliceFlag{
+					Name:  "ics",
+					Usage: "calendar file to read the next event from, instead of ics_files",
+				},
+				&cli.BoolFlag{
+					Name:    "ui",
+					Aliases: []string{"u"},
+					Usage:   "display interactive terminal UI",
+				},
+			},
+			Action: func(cCtx *cli.Context) error {
+				var target time.Time
+				if cCtx.Args().Present() {
+					var err error
+					if target, err = parseUntil(cCtx.Args().First(), clock.Now()); err != nil {
+						return err
+					}
+				} else {
+					event, err := untilNextEvent(cCtx.StringSlice("ics")...)
+					if err != nil {
+						return err
+					}
+					target = event.Start
+					fmt.Printf("Next event: %s at %s\n", event.Summary, event.Start.Format("15:04"))
+				}
+
+				mode := WorkSession
+				if cCtx.Bool("break") {
+					mode = BreakSession
+				}
+				if err := Until(target, mode, cCtx.String("task")); err != nil {
+					return err
+				}
+
+				if cCtx.Bool("ui") {
+					return StartUI()
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "plan",
+			Usage: "lay out the rest of the day around the events of the calendar",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "fit",
+					Usage: "shorten the running session to end before the next event",
+				},
+				&cli.StringSliceFlag{
+					Name:  "ics",
+					Usage: "calendar file to read the events from, instead of ics_files",
+				},
+			},
+			Action: func(cCtx *cli.Context) error {
+				return Plan(cCtx.Bool("fit"), cCtx.StringSlice("ics")...)
+			},
+			Subcommands: []*cli.Command{
+				{
+					Name:      "add",
+					Usage:     "plan a task for today",
+					ArgsUsage: "<task>",
+					Flags: []cli.Flag{
+						&cli.IntFlag{
+							Name:    "estimate",
+							Aliases: []string{"e"},
+							Value:   1,
+							Usage:   "number of pomodoros the task should take",
+						},
+					},
+					Action: func(cCtx *cli.Context) error {
+						task, estimate, err := planTaskArgs(cCtx.Args().Slice(), cCtx.Int("estimate"))
+						if err != nil {
+							return err
+						}
+						return PlanTask(task, estimate)
+					},
+				},
+				{
+					Name:  "review",
+					Usage: "compare the estimated and actual pomodoros of the planned tasks",
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "day",
+							Usage: "day to review, like 2024-01-02, defaults to today",
+						},
+					},
+					Action: func(cCtx *cli.Context) error {
+						day, err := parseDay(cCtx.String("day"))
+						if err != nil {
+							return err
+						}
+						return PlanReview(day)
+					},
+				},
+			},
+		},
+		{
+			Name:      "stop",
+			Usage:     "stop the pomodoro countdown, or the named timer",
+			ArgsUsage: "[timer]",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "note",
+					Aliases: []string{"n"},
+					Usage:   "note about what was done, written to the journal",
+				},
+			},
+			Action: func(cCtx *cli.Context) error {
+				if name := cCtx.Args().First(); name != "" {
+					return StopTimer(name)
+				}
+
 				var session Session
 				if err := session.Get(); err != nil {
 					return err
@@ -204,6 +398,10 @@ var App = &cli.App{
 					return fmt.Errorf("no session is running")
 				}
 
+				if note := cCtx.String("note"); note != "" {
+					session.Note = note
+				}
+
 				if err := session.Stop(); err != nil {
 					return err
 				}
@@ -212,48 +410,356 @@ var App = &cli.App{
 			},
 		},
 		{
-			Name:  "print",
-			Usage: "print current to standard output",
+			Name:      "start",
+			Usage:     "start a named timer beside the pomodoro",
+			ArgsUsage: "<duration>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "name",
+					Aliases:  []string{"n"},
+					Usage:    "name of the timer",
+					Required: true,
+				},
+			},
+			Action: func(cCtx *cli.Context) error {
+				duration, err := time.ParseDuration(cCtx.Args().First())
+				if err != nil {
+					return fmt.Errorf("error: the input must be like 1m, 1h, 1s, 1h30m, etc")
+				}
+				return StartTimer(cCtx.String("name"), duration)
+			},
+		},
+		{
+			Name:  "list",
+			Usage: "list the running session and timers",
 			Action: func(_ *cli.Context) error {
 				var session Session
 				if err := session.Get(); err != nil {
 					return err
 				}
 
-				if !session.isRunning() {
-					return nil
+				if session.ID != uuid.Nil && session.isRunning() {
+					fmt.Printf("%-10s %s\n", session.Type, StopWatchFormat(session.Elapsed()))
 				}
 
-				// Get elapsed time (remaining time)
-				remaining := session.Elapsed()
+				timers, err := ListTimers()
+				if err != nil {
+					return err
+				}
+				for _, timer := range timers {
+					fmt.Printf("%-10s %s\n", timer.Name, StopWatchFormat(timer.Elapsed()))
+				}
 
-				// Get configuration values
-				prefix := conf.QueryString("prefix")          // 🍅
-				prefixWarn := conf.QueryString("prefix_warn") // 💢
-				warn := conf.QueryString("warn")              // 1m
+				return nil
+			},
+		},
+		{
+			Name:      "extend",
+			Usage:     "add time to the running session",
+			ArgsUsage: "[duration]",
+			Action: func(cCtx *cli.Context) error {
+				return adjustSession(cCtx.Args().First(), 1)
+			},
+		},
+		{
+			Name:      "shorten",
+			Usage:     "remove time from the running session",
+			ArgsUsage: "[duration]",
+			Action: func(cCtx *cli.Context) error {
+				return adjustSession(cCtx.Args().First(), -1)
+			},
+		},
+		{
+			Name:      "snooze",
+			Usage:     "silence the expiry alerts by extending the running session",
+			ArgsUsage: "[duration]",
+			Action: func(cCtx *cli.Context) error {
+				arg := cCtx.Args().First()
+				if arg == "" {
+					arg = conf.QueryStringOr("snooze", Snooze)
+				}
+				step, err := adjustStep(arg)
+				if err != nil {
+					return err
+				}
 
-				// Parse warning threshold
-				warnTime, err := time.ParseDuration(warn)
+				var session Session
+				if err := session.Get(); err != nil {
+					return err
+				}
+
+				if err := session.Snooze(step); err != nil {
+					return err
+				}
+
+				fmt.Printf("Snoozed, %s left\n", StopWatchFormat(session.Elapsed()))
+				return nil
+			},
+		},
+		{
+			Name:  "daemon",
+			Usage: "send the session alerts in the background, without the UI",
+			Action: func(_ *cli.Context) error {
+				return Daemon()
+			},
+		},
+		{
+			Name:  "sound",
+			Usage: "manage the alert sounds",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "test",
+					Usage:     "play the sound of an event (work_end, break_end, warning, tick)",
+					ArgsUsage: "<event>",
+					Action: func(cCtx *cli.Context) error {
+						event := cCtx.Args().First()
+						if event == "" {
+							event = EventWorkEnd
+						}
+						return TestSound(event)
+					},
+				},
+			},
+		},
+		{
+			Name:  "webhooks",
+			Usage: "manage the webhook deliveries",
+			Subcommands: []*cli.Command{
+				{
+					Name:  "flush",
+					Usage: "retry the pending deliveries in the outbox",
+					Action: func(_ *cli.Context) error {
+						pending, err := FlushWebhooks()
+						if err != nil {
+							return err
+						}
+						fmt.Printf("%d deliveries pending\n", pending)
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "block",
+			Usage: "block the block_domains in the hosts file",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "print the changes instead of writing them",
+				},
+			},
+			Action: func(cCtx *cli.Context) error {
+				return Block(true, cCtx.Bool("dry-run") || conf.QueryBool("block_dry_run"))
+			},
+			Subcommands: []*cli.Command{
+				{
+					Name:  "off",
+					Usage: "remove the pomo block from the hosts file",
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "dry-run",
+							Usage: "print the changes instead of writing them",
+						},
+					},
+					Action: func(cCtx *cli.Context) error {
+						return Block(false, cCtx.Bool("dry-run") || conf.QueryBool("block_dry_run"))
+					},
+				},
+				{
+					Name:  "status",
+					Usage: "list the blocked domains",
+					Action: func(_ *cli.Context) error {
+						domains, err := BlockStatus()
+						if err != nil {
+							return err
+						}
+						for _, domain := range domains {
+							fmt.Println(domain)
+						}
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "journal",
+			Usage: "manage the journal of work sessions",
+			Subcommands: []*cli.Command{
+				{
+					Name:  "rebuild",
+					Usage: "regenerate the journal from the sessions log",
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "from",
+							Usage: "first day to include, as YYYY-MM-DD",
+						},
+						&cli.StringFlag{
+							Name:  "to",
+							Usage: "last day to include, as YYYY-MM-DD, defaults to today",
+						},
+						&cli.StringFlag{
+							Name:    "output",
+							Aliases: []string{"o"},
+							Usage:   "file to write, defaults to the journal_file",
+						},
+						&cli.BoolFlag{
+							Name:  "force",
+							Usage: "overwrite the file, dropping the sessions outside of the days",
+						},
+					},
+					Action: func(cCtx *cli.Context) error {
+						from, to, err := dayRange(cCtx.String("from"), cCtx.String("to"))
+						if err != nil {
+							return err
+						}
+
+						return RebuildJournal(from, to, cCtx.String("output"), cCtx.Bool("force"))
+					},
+				},
+			},
+		},
+		{
+			Name:  "report",
+			Usage: "list the sessions and the time spent per file",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "from",
+					Usage: "first day to include, as YYYY-MM-DD, defaults to today",
+				},
+				&cli.StringFlag{
+					Name:  "to",
+					Usage: "last day to include, as YYYY-MM-DD, defaults to today",
+				},
+				&cli.BoolFlag{
+					Name:  "commits",
+					Usage: "list the git commits authored during each work session",
+				},
+			},
+			Action: func(cCtx *cli.Context) error {
+				from, to, err := dayRange(cCtx.String("from"), cCtx.String("to"))
 				if err != nil {
 					return err
 				}
+				if from.IsZero() {
+					// today, which starts at the day_start
+					from, _ = dayBounds(clock.Now())
+				}
 
-				var timeStr string
-				if remaining < 0 {
-					// For negative time, remove the minus and add a "-" prefix to the formatted time
-					timeStr = "-" + StopWatchFormat(-remaining)
-				} else {
-					timeStr = StopWatchFormat(remaining)
-					// Switch to warning prefix when less than 1 minute remains
-					// and blink every 2 seconds
-					if remaining < warnTime && remaining%(time.Second*2) == 0 {
-						prefix = prefixWarn
+				return Report(from, to, cCtx.Bool("commits"))
+			},
+		},
+		{
+			Name:  "git",
+			Usage: "git integration",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "install-hook",
+					Usage:     "add a Pomodoro trailer to the commits made during work sessions",
+					ArgsUsage: "[repository]",
+					Action: func(cCtx *cli.Context) error {
+						dir := cCtx.Args().First()
+						if dir == "" {
+							dir = "."
+						}
+						return InstallGitHook(dir)
+					},
+				},
+			},
+		},
+		{
+			Name:  "rpc",
+			Usage: "serve editors over JSON-RPC on stdio or a unix socket",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "socket",
+					Usage: "path of the unix socket to listen on instead of stdio",
+				},
+			},
+			Action: func(cCtx *cli.Context) error {
+				return ServeRPC(cCtx.String("socket"))
+			},
+		},
+		{
+			Name:      "focus",
+			Usage:     "record the file being worked on",
+			ArgsUsage: "<path>",
+			Action: func(cCtx *cli.Context) error {
+				if !cCtx.Args().Present() {
+					return fmt.Errorf("error: a path is required")
+				}
+				path, err := filepath.Abs(cCtx.Args().First())
+				if err != nil {
+					return err
+				}
+				return Focus(path)
+			},
+		},
+		{
+			Name:  "print",
+			Usage: "print current to standard output",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "format",
+					Aliases: []string{"f"},
+					Usage:   "go template used while a session is running",
+				},
+				&cli.StringFlag{
+					Name:  "idle",
+					Usage: "go template used when no session is running",
+				},
+				&cli.BoolFlag{
+					Name:  "waybar",
+					Usage: "print waybar JSON with text, tooltip, class and percentage",
+				},
+				&cli.BoolFlag{
+					Name:  "i3blocks",
+					Usage: "print i3blocks full text, short text and color",
+				},
+				&cli.BoolFlag{
+					Name:  "polybar",
+					Usage: "print polybar colour and click-to-stop action tags",
+				},
+				&cli.BoolFlag{
+					Name:  "tmux",
+					Usage: "print with tmux colour escapes",
+				},
+				&cli.BoolFlag{
+					Name:  "follow",
+					Usage: "keep printing an update every second",
+				},
+				&cli.BoolFlag{
+					Name:  "id",
+					Usage: "print only the id of the running work session, nothing otherwise",
+				},
+			},
+			Action: func(cCtx *cli.Context) error {
+				if cCtx.Bool("id") {
+					return PrintRunningID()
+				}
+
+				format := cCtx.String("format")
+				if format == "" {
+					format = conf.QueryStringOr("print_format", PrintFormat)
+				}
+
+				idle := cCtx.String("idle")
+				if idle == "" {
+					idle = conf.QueryStringOr("print_idle_format", "")
+				}
+
+				var mode string
+				for _, m := range []string{BarWaybar, BarI3blocks, BarPolybar, BarTmux} {
+					if cCtx.Bool(m) {
+						mode = m
+						break
 					}
 				}
 
-				fmt.Printf("%v %v", prefix, timeStr)
+				if mode == "" && !cCtx.Bool("follow") {
+					return Print(format, idle)
+				}
 
-				return nil
+				return PrintBar(mode, format, idle, cCtx.Bool("follow"))
 			},
 		},
 		{
@@ -270,6 +776,23 @@ var App = &cli.App{
 				conf.Set("warn", Warn)
 				conf.Set("prefix", WorkPrefix)
 				conf.Set("prefix_warn", WarnPrefix)
+				conf.Set("day_start", DayStart)
+				conf.Set("max_session", MaxSession)
+				conf.Set("stale_after", StaleAfter)
+				conf.Set("stale_policy", RecoverPrompt)
+				conf.Set("extend", Extend)
+				conf.Set("max_extensions", MaxExtensions)
+				conf.Set("snooze", Snooze)
+				conf.Set("alert_intervals", []string{AlertInterval})
+				conf.Set("alert_max_repeats", AlertMaxRepeats)
+				conf.Set("alert_urgency", []string{UrgencyNormal, UrgencyNormal, UrgencyCritical})
+				conf.Set("warn_thresholds", []string{"5m", Warn})
+				conf.Set("warn_sound", false)
+				conf.Set("sound_volume", SoundVolume)
+				conf.Set("ticking", false)
+				conf.Set("plan_end", PlanEnd)
+				conf.Set("long_break_after", LongBreakAfter)
+				conf.Set("plan_auto_task", false)
 
 				return nil
 			},
@@ -300,6 +823,23 @@ var App = &cli.App{
 				return ShowStatus()
 			},
 		},
+		{
+			Name:  "stats",
+			Usage: "show the focus analytics of the day and its week",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "day",
+					Usage: "day to show, like 2024-01-02, defaults to today",
+				},
+			},
+			Action: func(cCtx *cli.Context) error {
+				day, err := parseDay(cCtx.String("day"))
+				if err != nil {
+					return err
+				}
+				return Stats(day)
+			},
+		},
 		{
 			Name: "sessions",
 			Subcommands: []*cli.Command{
@@ -314,7 +854,80 @@ var App = &cli.App{
 						return Editor(path)
 					},
 				},
+				{
+					Name:  "check",
+					Usage: "List the lines of the sessions file that cannot be read",
+					Action: func(_ *cli.Context) error {
+						if _, err := index.Sessions(); err != nil {
+							return err
+						}
+						malformed := index.Malformed()
+						for _, m := range malformed {
+							fmt.Printf("%d: %v\n    %s\n", m.Line, m.Err, m.Text)
+						}
+						if len(malformed) > 0 {
+							return fmt.Errorf("%d malformed sessions", len(malformed))
+						}
+						return nil
+					},
+				},
+				{
+					Name:  "recover",
+					Usage: "End dangling sessions that were left running",
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "policy",
+							Usage: "end sessions at their planned end, last activity or now (prompt, planned, activity, now)",
+						},
+					},
+					Action: func(cCtx *cli.Context) error {
+						policy := cCtx.String("policy")
+						if policy == "" {
+							policy = conf.QueryStringOr("stale_policy", RecoverPrompt)
+						}
+
+						recovered, err := RecoverSessions(policy)
+						if err != nil {
+							return err
+						}
+
+						fmt.Printf("Recovered %d session(s)\n", recovered)
+						return nil
+					},
+				},
 			},
 		},
 	},
 }
+
+func adjustSession(arg string, sign time.Duration) error {
+	step, err := adjustStep(arg)
+	if err != nil {
+		return err
+	}
+
+	var session Session
+	if err := session.Get(); err != nil {
+		return err
+	}
+
+	if err := session.Adjust(sign * step); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s session is now %s long\n", session.Type, session.Duration)
+	return nil
+}
+
+// before runs ahead of every command to clean up after sessions that ended
+// without pomo noticing.
+func before(cCtx *cli.Context) error {
+	if err := checkStale(cCtx); err != nil {
+		return err
+	}
+
+	if cCtx.Args().First() != "init" && Exists(conf.DirPath()) {
+		recoverDND()
+	}
+	return nil
+}
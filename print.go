@@ -0,0 +1,169 @@
+package pomo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// PrintFormat is the default template used by `pomo print`.
+	PrintFormat = "{{.Prefix}} {{stopwatch .Remaining}}"
+)
+
+// printData is the value passed to the `pomo print` templates.
+type printData struct {
+	ID        string
+	Prefix    string
+	Type      SessionType
+	Task      string
+	File      string
+	Remaining time.Duration // time left, negative once overrun
+	Elapsed   time.Duration // time since the session started
+	Duration  time.Duration
+	Cycle     int     // number of the work session of the day
+	Percent   float64 // elapsed time relative to the duration
+	Warn      bool
+	Overrun   bool
+	Running   bool
+	Class     string // idle, work, break, longbreak, warn or overrun
+}
+
+var printFuncs = template.FuncMap{
+	"stopwatch": StopWatchFormat,
+	"hm":        formatDurationHm,
+	"bar":       progressBar,
+	"mins": func(d time.Duration) int {
+		return int(d / time.Minute)
+	},
+}
+
+// Print writes the current session to standard output using the given
+// template. When no session is running the idle template is used instead,
+// an empty idle template prints nothing.
+func Print(format, idle string) error {
+	data, err := currentPrintData()
+	if err != nil {
+		return err
+	}
+
+	text, err := renderPrint(data, format, idle)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(text)
+	return nil
+}
+
+func renderPrint(data printData, format, idle string) (string, error) {
+	if !data.Running {
+		format = idle
+	}
+	if format == "" {
+		return "", nil
+	}
+
+	tmpl, err := template.New("print").Funcs(printFuncs).Parse(format)
+	if err != nil {
+		return "", fmt.Errorf("invalid print format: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func currentPrintData() (printData, error) {
+	var session Session
+	if err := session.Get(); err != nil {
+		return printData{}, err
+	}
+
+	data := printData{
+		Prefix: conf.QueryStringOr("prefix", WorkPrefix),
+		Class:  "idle",
+	}
+	if session.ID == uuid.Nil || !session.isRunning() {
+		return data, nil
+	}
+
+	remaining := session.Elapsed()
+
+	warnTime, err := time.ParseDuration(conf.QueryStringOr("warn", Warn))
+	if err != nil {
+		return printData{}, err
+	}
+
+	data.ID = session.ID.String()
+	data.Type = session.Type
+	data.Task = session.Task
+	data.File = session.currentFile()
+	data.Remaining = remaining
+	data.Elapsed = since(session.StartTime)
+	data.Duration = session.Duration
+	data.Running = true
+	data.Overrun = remaining < 0
+	data.Warn = !data.Overrun && remaining < warnTime
+	switch {
+	case data.Overrun:
+		data.Class = "overrun"
+	case data.Warn:
+		data.Class = "warn"
+	default:
+		data.Class = string(session.Type)
+	}
+	if session.Duration > 0 {
+		data.Percent = float64(data.Elapsed) / float64(session.Duration) * 100
+	}
+
+	// Switch to warning prefix when less than the warn threshold remains
+	// and blink every 2 seconds
+	if data.Warn && (remaining/time.Second)%2 == 0 {
+		data.Prefix = conf.QueryStringOr("prefix_warn", WarnPrefix)
+	}
+
+	sessions, err := ListSessions()
+	if err != nil {
+		return printData{}, err
+	}
+	data.Cycle = workCycle(sessions, session)
+
+	return data, nil
+}
+
+// workCycle returns how many work sessions were started on the day of the
+// given session, up to and including it.
+func workCycle(sessions []Session, current Session) int {
+	from, _ := dayBounds(current.StartTime)
+
+	var cycle int
+	for _, s := range sessions {
+		if s.Type != WorkSession || s.StartTime.Before(from) {
+			continue
+		}
+		if s.StartTime.After(current.StartTime) {
+			break
+		}
+		cycle++
+	}
+	return cycle
+}
+
+// progressBar draws a bar of the given width filled up to percentage.
+func progressBar(percentage float64, width int) string {
+	filled := int(percentage * float64(width) / 100)
+	if filled > width {
+		filled = width
+	}
+	if filled < 0 {
+		filled = 0
+	}
+	return strings.Repeat("━", filled) + strings.Repeat("─", width-filled)
+}
@@ -0,0 +1,98 @@
+package pomo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPrintWarnBlink(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	fake := setupTest(t, start, map[string]any{
+		"warn":        "1m",
+		"prefix":      WorkPrefix,
+		"prefix_warn": WarnPrefix,
+	})
+	writeSessions(t, Session{ID: uuid.New(), StartTime: start, Duration: 25 * time.Minute, Type: WorkSession})
+
+	tests := []struct {
+		at     time.Duration
+		warn   bool
+		prefix string
+		class  string
+	}{
+		{10 * time.Minute, false, WorkPrefix, "work"},
+		{24*time.Minute - time.Second, false, WorkPrefix, "work"},
+		// 30s left, the prefix blinks every other second
+		{24*time.Minute + 30*time.Second, true, WarnPrefix, "warn"},
+		{24*time.Minute + 31*time.Second, true, WorkPrefix, "warn"},
+		{24*time.Minute + 32*time.Second, true, WarnPrefix, "warn"},
+		{26 * time.Minute, false, WorkPrefix, "overrun"},
+	}
+
+	for _, tt := range tests {
+		fake.Set(start.Add(tt.at))
+
+		data, err := currentPrintData()
+		if err != nil {
+			t.Fatalf("currentPrintData() at +%s: %v", tt.at, err)
+		}
+		if !data.Running {
+			t.Fatalf("currentPrintData() at +%s is not running", tt.at)
+		}
+		if data.Warn != tt.warn || data.Prefix != tt.prefix || data.Class != tt.class {
+			t.Errorf("at +%s: warn %v, prefix %q, class %q, want %v, %q, %q",
+				tt.at, data.Warn, data.Prefix, data.Class, tt.warn, tt.prefix, tt.class)
+		}
+	}
+}
+
+func TestPrintIdle(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupTest(t, start, nil)
+	writeSessions(t, Session{
+		ID:        uuid.New(),
+		StartTime: start.Add(-time.Hour),
+		EndTime:   start.Add(-35 * time.Minute),
+		Duration:  25 * time.Minute,
+		Type:      WorkSession,
+	})
+
+	data, err := currentPrintData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Running || data.Class != "idle" {
+		t.Errorf("currentPrintData() = running %v, class %q, want idle", data.Running, data.Class)
+	}
+
+	out, err := renderPrint(data, PrintFormat, "idle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "idle" {
+		t.Errorf("renderPrint() = %q, want the idle format", out)
+	}
+}
+
+func TestPrintCycle(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupTest(t, start.Add(time.Hour), nil)
+
+	done := Session{ID: uuid.New(), StartTime: start, EndTime: start.Add(25 * time.Minute), Duration: 25 * time.Minute, Type: WorkSession}
+	pause := Session{ID: uuid.New(), StartTime: done.EndTime, EndTime: done.EndTime.Add(5 * time.Minute), Duration: 5 * time.Minute, Type: BreakSession}
+	running := Session{ID: uuid.New(), StartTime: start.Add(50 * time.Minute), Duration: 25 * time.Minute, Type: WorkSession}
+	writeSessions(t, done, pause, running)
+
+	data, err := currentPrintData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Cycle != 2 {
+		t.Errorf("Cycle = %d, want 2", data.Cycle)
+	}
+	if data.Remaining != 15*time.Minute {
+		t.Errorf("Remaining = %s, want 15m", data.Remaining)
+	}
+}
@@ -0,0 +1,82 @@
+package pomo
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Report prints the work sessions between from and to followed by the time
+// spent per file. With commits set the commits authored during each session
+// are listed under it.
+func Report(from, to time.Time, commits bool) error {
+	sessions, err := ListSessions()
+	if err != nil {
+		return err
+	}
+
+	var work []Session
+	for _, s := range filterSessions(sessions, from, to) {
+		if s.Type == WorkSession {
+			work = append(work, s)
+		}
+	}
+
+	if len(work) == 0 {
+		fmt.Println("No work sessions")
+		return nil
+	}
+
+	var planned, extended time.Duration
+	for _, s := range work {
+		p, e := s.splitTime()
+		planned, extended = planned+p, extended+e
+
+		line := fmt.Sprintf("%s %s–%s %6s  %s",
+			s.StartTime.Format("2006-01-02"),
+			s.StartTime.Format("15:04"),
+			sessionEnd(s).Format("15:04"),
+			formatDurationHm(sessionEnd(s).Sub(s.StartTime)),
+			journalTask(s),
+		)
+		if s.Repo != "" {
+			line += fmt.Sprintf(" (%s@%s)", filepath.Base(s.Repo), s.Branch)
+		}
+		if e > 0 {
+			line += fmt.Sprintf(" [%s planned +%s extended]", formatDurationHm(p), formatDurationHm(e))
+		}
+		fmt.Println(line)
+
+		if !commits {
+			continue
+		}
+		list, err := sessionCommits(s)
+		if err != nil {
+			fmt.Printf("    failed to list commits: %v\n", err)
+			continue
+		}
+		for _, c := range list {
+			fmt.Printf("    %s %s\n", c.Hash, c.Subject)
+		}
+	}
+
+	fmt.Printf("\nPlanned %s, extended %s\n", formatDurationHm(planned), formatDurationHm(extended))
+
+	_, files := summarizeSessions(work, from, to)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return files[names[i]] > files[names[j]]
+	})
+
+	fmt.Println("\nFiles")
+	for _, name := range names {
+		fmt.Printf("%8s  %s\n", formatDurationHm(files[name]), name)
+	}
+
+	return nil
+}
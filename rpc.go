@@ -0,0 +1,339 @@
+package pomo
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcState is sent with every tick and returned by the state method.
+type rpcState struct {
+	Running   bool        `json:"running"`
+	ID        string      `json:"id,omitempty"`
+	Type      SessionType `json:"type,omitempty"`
+	Task      string      `json:"task,omitempty"`
+	File      string      `json:"file,omitempty"`
+	Class     string      `json:"class"`
+	Text      string      `json:"text"`
+	Remaining int64       `json:"remaining"` // seconds, negative once overrun
+	Duration  int64       `json:"duration"`  // seconds
+}
+
+type rpcFocusParams struct {
+	File string `json:"file"`
+}
+
+type rpcStartParams struct {
+	Type     SessionType `json:"type"`
+	Duration string      `json:"duration"`
+	Task     string      `json:"task"`
+}
+
+type rpcStopParams struct {
+	Note string `json:"note"`
+}
+
+// rpcConn is an editor connected to `pomo rpc`. Messages are JSON-RPC 2.0
+// objects, one per line, in both directions. Editors call:
+//
+//	focus {"file": "/path"}                           the active buffer changed
+//	start {"type": "work", "duration": "25m", "task": "..."}
+//	stop  {"note": "..."}
+//	state                                             returns the current state
+//
+// and receive a tick notification with the state every second.
+type rpcConn struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// ServeRPC serves one editor over stdin and stdout, or every editor that
+// connects to the unix socket when socket is set.
+func ServeRPC(socket string) error {
+	if socket == "" {
+		// keep stdout for the protocol, anything else printed goes to stderr
+		out := os.Stdout
+		os.Stdout = os.Stderr
+		return serveRPC(os.Stdin, out)
+	}
+
+	listener, err := listenRPC(socket)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	return acceptRPC(listener)
+}
+
+// listenRPC listens on the unix socket, replacing a socket left by a
+// previous pomo rpc that did not exit cleanly.
+func listenRPC(socket string) (net.Listener, error) {
+	if info, err := os.Lstat(socket); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s already exists and is not a socket", socket)
+		}
+		if conn, err := net.Dial("unix", socket); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is already served by another process", socket)
+		}
+		if err := Remove(socket); err != nil {
+			return nil, err
+		}
+	}
+
+	return net.Listen("unix", socket)
+}
+
+// acceptRPC serves every connection of the listener until it is closed.
+func acceptRPC(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			if err := serveRPC(conn, conn); err != nil {
+				fmt.Fprintf(os.Stderr, "rpc: %v\n", err)
+			}
+		}()
+	}
+}
+
+func serveRPC(r io.Reader, w io.Writer) error {
+	c := &rpcConn{enc: json.NewEncoder(w)}
+
+	done := make(chan struct{})
+	defer close(done)
+	go c.ticks(done)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req rpcRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			c.send(rpcResponse{
+				JSONRPC: "2.0",
+				ID:      json.RawMessage("null"),
+				Error:   &rpcError{Code: rpcParseError, Message: err.Error()},
+			})
+			continue
+		}
+
+		result, rerr := handleRPC(req)
+
+		// requests without an id are notifications and get no answer
+		if len(req.ID) == 0 {
+			continue
+		}
+		resp := rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rerr}
+		if rerr == nil && result == nil {
+			resp.Result = true
+		}
+		c.send(resp)
+	}
+
+	return scanner.Err()
+}
+
+func handleRPC(req rpcRequest) (interface{}, *rpcError) {
+	switch req.Method {
+	case "state":
+		return rpcStateResult()
+
+	case "focus":
+		var params rpcFocusParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.File == "" {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "expected {\"file\": \"...\"}"}
+		}
+		if err := Focus(params.File); err != nil {
+			return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+		}
+		return nil, nil
+
+	case "start":
+		var params rpcStartParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+			}
+		}
+		if err := rpcStart(params); err != nil {
+			return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+		}
+		return rpcStateResult()
+
+	case "stop":
+		var params rpcStopParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+			}
+		}
+		if err := rpcStop(params); err != nil {
+			return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+		}
+		return rpcStateResult()
+	}
+
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)}
+}
+
+func rpcStart(params rpcStartParams) error {
+	if params.Type == "" {
+		params.Type = WorkSession
+	}
+
+	key, def, prefix := "duration", Duration, WorkPrefix
+	if params.Type != WorkSession {
+		key, def, prefix = "break", Break, BreakPrefix
+	}
+	if params.Duration == "" {
+		params.Duration = conf.QueryStringOr(key, def)
+	}
+
+	duration, err := time.ParseDuration(params.Duration)
+	if err != nil {
+		return err
+	}
+
+	var session Session
+	if err := session.Get(); err != nil {
+		return err
+	}
+	if session.ID != uuid.Nil && session.isRunning() {
+		if err := session.Stop(); err != nil {
+			return err
+		}
+	}
+
+	next := Session{Task: params.Task}
+	if err := next.Start(conf, duration, params.Type); err != nil {
+		return err
+	}
+
+	return conf.Set("prefix", prefix)
+}
+
+func rpcStop(params rpcStopParams) error {
+	var session Session
+	if err := session.Get(); err != nil {
+		return err
+	}
+	if session.ID == uuid.Nil || !session.isRunning() {
+		return fmt.Errorf("no session is running")
+	}
+
+	if params.Note != "" {
+		session.Note = params.Note
+	}
+	return session.Stop()
+}
+
+func rpcStateResult() (interface{}, *rpcError) {
+	state, err := currentRPCState()
+	if err != nil {
+		return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+	}
+	return state, nil
+}
+
+func currentRPCState() (rpcState, error) {
+	data, err := currentPrintData()
+	if err != nil {
+		return rpcState{}, err
+	}
+
+	text, err := renderPrint(data,
+		conf.QueryStringOr("print_format", PrintFormat),
+		conf.QueryStringOr("print_idle_format", ""))
+	if err != nil {
+		return rpcState{}, err
+	}
+
+	return rpcState{
+		Running:   data.Running,
+		ID:        data.ID,
+		Type:      data.Type,
+		Task:      data.Task,
+		File:      data.File,
+		Class:     data.Class,
+		Text:      text,
+		Remaining: int64(data.Remaining.Seconds()),
+		Duration:  int64(data.Duration.Seconds()),
+	}, nil
+}
+
+// ticks sends the state to the editor every second until done is closed.
+func (c *rpcConn) ticks(done chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			state, err := currentRPCState()
+			if err != nil {
+				continue
+			}
+			c.send(rpcNotification{JSONRPC: "2.0", Method: "tick", Params: state})
+		}
+	}
+}
+
+func (c *rpcConn) send(msg interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// the encoder ends every message with a newline
+	if err := c.enc.Encode(msg); err != nil {
+		fmt.Fprintf(os.Stderr, "rpc: %v\n", err)
+	}
+}
@@ -0,0 +1,72 @@
+package pomo
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServeRPCExistingSocket(t *testing.T) {
+	setupTest(t, time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), nil)
+	writeSessions(t)
+	dir := t.TempDir()
+
+	// a regular file is never removed
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ServeRPC(file); err == nil {
+		t.Error("ServeRPC() on a regular file succeeded")
+	}
+	if got, _ := Read(file); got != "keep" {
+		t.Errorf("file = %q, want it untouched", got)
+	}
+
+	// nor is a socket that is still served
+	live := filepath.Join(dir, "live.sock")
+	listener, err := net.Listen("unix", live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	if err := ServeRPC(live); err == nil {
+		t.Error("ServeRPC() on a served socket succeeded")
+	}
+	if conn, err := net.Dial("unix", live); err != nil {
+		t.Errorf("the served socket was removed: %v", err)
+	} else {
+		conn.Close()
+	}
+
+	// a socket left behind is replaced
+	stale := filepath.Join(dir, "stale.sock")
+	l, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+
+	served, err := listenRPC(stale)
+	if err != nil {
+		t.Fatalf("listenRPC() on a stale socket = %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		acceptRPC(served)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		served.Close()
+		<-done
+	})
+
+	conn, err := net.Dial("unix", stale)
+	if err != nil {
+		t.Fatalf("the stale socket is not served: %v", err)
+	}
+	conn.Close()
+}
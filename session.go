@@ -2,8 +2,10 @@ package pomo
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,15 +19,34 @@ const (
 	WorkSession      SessionType = "work"
 	BreakSession     SessionType = "break"
 	LongBreakSession SessionType = "longbreak"
+	TimerSession     SessionType = "timer"
 )
 
 type Session struct {
 	ID        uuid.UUID
+	Name      string // set on named timers running beside the pomodoro
 	StartTime time.Time
 	EndTime   time.Time
 	Duration  time.Duration
 	Type      SessionType
-	File      string
+	Until     time.Time // wall clock target of sessions started with until
+	Task      string
+	Project   string
+	TW        string // taskwarrior uuid of the task
+	Note      string
+	Repo      string // git repository of File
+	Branch    string
+	File      string // file open when the session started
+
+	// Focus records the files switched to during the session.
+	Focus []FocusChange
+
+	// Adjustments made to the planned duration with extend and shorten,
+	// Duration already includes them.
+	Adjustments []time.Duration
+	// Snoozes made to the planned duration, kept apart from the
+	// adjustments as they are not limited by max_extensions.
+	Snoozes []time.Duration
 }
 
 const SESSION_FILENAME = "session.log"
@@ -34,33 +55,48 @@ func (s *Session) Elapsed() time.Duration {
 	if !s.isRunning() {
 		return 0
 	}
-	targetEnd := s.StartTime.Add(s.Duration)
-	return targetEnd.Sub(time.Now())
+	return s.plannedEnd().Sub(clock.Now())
+}
+
+// plannedEnd returns when the session is meant to end, the target of
+// sessions started with until or start + duration.
+func (s *Session) plannedEnd() time.Time {
+	if !s.Until.IsZero() {
+		return s.Until
+	}
+	return s.StartTime.Add(s.Duration)
 }
 
 func (s *Session) Start(conf Conf, dur time.Duration, mode SessionType) error {
+	return s.start(conf, dur, time.Time{}, mode)
+}
+
+// StartUntil starts a session that ends at the wall clock time until.
+func (s *Session) StartUntil(conf Conf, until time.Time, mode SessionType) error {
+	return s.start(conf, 0, until, mode)
+}
+
+func (s *Session) start(conf Conf, dur time.Duration, until time.Time, mode SessionType) error {
 	s.ID = uuid.New()
-	s.StartTime = time.Now()
+	s.StartTime = clock.Now()
 	s.Duration = dur
+	s.Until = until
+	if !until.IsZero() {
+		s.Duration = until.Sub(s.StartTime)
+	}
 	s.EndTime = time.Time{} // empty time
 	s.Type = mode
+	s.Adjustments = nil
+	s.Snoozes = nil
 
 	dir := conf.DirPath()
 	if !Exists(dir) {
 		return fmt.Errorf("could not resolve config path for %q", conf.Id)
 	}
 
-	homedir, err := os.UserHomeDir()
-	if err != nil {
-		return err
-	}
-
-	currentFile, err := Read(filepath.Join(homedir, ".nvim-buf"))
-	if err != nil {
-		return err
-	}
-
-	s.File = currentFile
+	s.File, _ = activeFile()
+	s.Focus = nil
+	s.Repo, s.Branch = detectGit(s.File)
 
 	sessionPath, err := sessionPath()
 	if err != nil {
@@ -71,41 +107,98 @@ func (s *Session) Start(conf Conf, dur time.Duration, mode SessionType) error {
 		return err
 	}
 
+	publish(EventStart, *s)
+
 	return nil
 }
 
+// Get loads the last pomodoro session of the log, named timers are skipped.
 func (s *Session) Get() error {
-	sessionPath, err := sessionPath()
-	if err != nil {
+	return s.get("")
+}
+
+// GetTimer loads the last timer of the log with the given name.
+func (s *Session) GetTimer(name string) error {
+	if err := s.get(name); err != nil {
 		return err
 	}
+	if s.ID == uuid.Nil {
+		return fmt.Errorf("no timer named %q", name)
+	}
+	return nil
+}
 
-	lines, err := ReadLines(sessionPath)
+func (s *Session) get(name string) error {
+	sessions, err := index.Sessions()
 	if err != nil {
 		return err
 	}
 
-	if len(lines) == 0 {
-		return nil
-	}
-
-	lastLine := lines[len(lines)-1]
-
-	if err := s.Scan(lastLine); err != nil {
-		return err
+	for i := len(sessions) - 1; i >= 0; i-- {
+		if sessions[i].Name == name {
+			*s = sessions[i]
+			return nil
+		}
 	}
 
 	return nil
 }
 
 func (s *Session) String() string {
+	var extra string
+	if s.Name != "" {
+		extra += " name=" + url.QueryEscape(s.Name)
+	}
+	if !s.Until.IsZero() {
+		extra += " until=" + s.Until.Format(time.RFC3339)
+	}
+	if s.Task != "" {
+		extra += " task=" + url.QueryEscape(s.Task)
+	}
+	if s.Project != "" {
+		extra += " project=" + url.QueryEscape(s.Project)
+	}
+	if s.TW != "" {
+		extra += " tw=" + s.TW
+	}
+	if s.Note != "" {
+		extra += " note=" + url.QueryEscape(s.Note)
+	}
+	if s.Repo != "" {
+		extra += " repo=" + url.QueryEscape(s.Repo)
+	}
+	if s.Branch != "" {
+		extra += " branch=" + url.QueryEscape(s.Branch)
+	}
+	if len(s.Focus) > 0 {
+		changes := make([]string, 0, len(s.Focus))
+		for _, f := range s.Focus {
+			changes = append(changes, fmt.Sprintf("%d:%s", int64(f.At.Sub(s.StartTime).Seconds()), url.QueryEscape(f.File)))
+		}
+		extra += " focus=" + strings.Join(changes, ",")
+	}
+	if len(s.Adjustments) > 0 {
+		adjustments := make([]string, 0, len(s.Adjustments))
+		for _, adj := range s.Adjustments {
+			adjustments = append(adjustments, adj.String())
+		}
+		extra += " adjust=" + strings.Join(adjustments, ",")
+	}
+	if len(s.Snoozes) > 0 {
+		snoozes := make([]string, 0, len(s.Snoozes))
+		for _, snooze := range s.Snoozes {
+			snoozes = append(snoozes, snooze.String())
+		}
+		extra += " snooze=" + strings.Join(snoozes, ",")
+	}
 	return fmt.Sprintf(
-		"id=%s type=%s start=%s end=%s duration=%s | %s",
+		"id=%s type=%s start=%s end=%s duration=%s%s | %s",
 		s.ID,
 		s.Type,
 		s.StartTime.Format(time.RFC3339),
 		s.EndTime.Format(time.RFC3339),
 		s.Duration,
+		extra,
 		s.File,
 	)
 }
@@ -126,9 +219,98 @@ func (s *Session) Scan(line string) error {
 		key, value := keyValue[0], keyValue[1]
 		switch key {
 		case "id":
-			s.ID = uuid.MustParse(value)
+			id, err := uuid.Parse(value)
+			if err != nil {
+				return err
+			}
+			s.ID = id
 		case "type":
 			s.Type = SessionType(value)
+		case "name":
+			name, err := url.QueryUnescape(value)
+			if err != nil {
+				return err
+			}
+			s.Name = name
+		case "until":
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return err
+			}
+			s.Until = t
+		case "task":
+			task, err := url.QueryUnescape(value)
+			if err != nil {
+				return err
+			}
+			s.Task = task
+		case "project":
+			project, err := url.QueryUnescape(value)
+			if err != nil {
+				return err
+			}
+			s.Project = project
+		case "tw":
+			// run by the hooks, it must not be anything but a uuid
+			if _, err := uuid.Parse(value); err != nil {
+				return fmt.Errorf("invalid taskwarrior uuid %q", value)
+			}
+			s.TW = value
+		case "note":
+			note, err := url.QueryUnescape(value)
+			if err != nil {
+				return err
+			}
+			s.Note = note
+		case "repo":
+			repo, err := url.QueryUnescape(value)
+			if err != nil {
+				return err
+			}
+			s.Repo = repo
+		case "branch":
+			branch, err := url.QueryUnescape(value)
+			if err != nil {
+				return err
+			}
+			s.Branch = branch
+		case "focus":
+			s.Focus = nil
+			for _, change := range strings.Split(value, ",") {
+				parts := strings.SplitN(change, ":", 2)
+				if len(parts) != 2 {
+					return fmt.Errorf("session detail format error: ':' separator not found in %s", change)
+				}
+				offset, err := strconv.ParseInt(parts[0], 10, 64)
+				if err != nil {
+					return err
+				}
+				file, err := url.QueryUnescape(parts[1])
+				if err != nil {
+					return err
+				}
+				// start is always written before focus
+				at := s.StartTime.Add(time.Duration(offset) * time.Second)
+				s.Focus = append(s.Focus, FocusChange{File: file, At: at})
+			}
+		case "adjust":
+			s.Adjustments = nil
+			for _, adj := range strings.Split(value, ",") {
+				dur, err := time.ParseDuration(adj)
+				if err != nil {
+					return err
+				}
+				s.Adjustments = append(s.Adjustments, dur)
+			}
+		case "snooze":
+			s.Snoozes = nil
+			for _, snooze := range strings.Split(value, ",") {
+				dur, err := time.ParseDuration(snooze)
+				if err != nil {
+					return err
+				}
+				s.Snoozes = append(s.Snoozes, dur)
+			}
 		case "duration":
 			dur, err := time.ParseDuration(value)
 			if err != nil {
@@ -184,16 +366,25 @@ func (s *Session) Save() error {
 		return nil
 	}
 
-	return Write(sessionPath, strings.Join(lines, "\n"))
+	return WriteReplace(sessionPath, joinLines(lines))
 }
 
 func (s *Session) Stop() error {
-	s.EndTime = time.Now()
-	return s.Save()
+	s.EndTime = clock.Now()
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	publish(EventStop, *s)
+
+	return nil
 }
 
 func (s *Session) Reset() error {
-	s.StartTime = time.Now()
+	// the focus changes are kept as offsets from the start, the session
+	// starts over on the file last in focus
+	s.File, s.Focus = s.currentFile(), nil
+	s.StartTime = clock.Now()
 	s.EndTime = time.Time{}
 	return s.Save()
 }
@@ -221,13 +412,28 @@ func (s *Session) Delete() error {
 		}
 	}
 
-	return Write(sessionPath, strings.Join(lines, "\n"))
+	return WriteReplace(sessionPath, joinLines(lines))
+}
+
+// joinLines joins the lines of the session log, each ending with a newline.
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
 }
 
 func (s *Session) isRunning() bool {
 	return s.EndTime.IsZero()
 }
 
+// isCompleted tells whether the session is a completed pomodoro: a work
+// session, not a named timer, stopped at or after its planned end with the
+// extensions and snoozes.
+func (s *Session) isCompleted() bool {
+	return s.Type == WorkSession && s.Name == "" && !s.isRunning() && !s.EndTime.Before(s.plannedEnd())
+}
+
 func sessionPath() (string, error) {
 	dir := conf.DirPath()
 	if !Exists(dir) {
@@ -247,27 +453,14 @@ func sessionPath() (string, error) {
 	return path, nil
 }
 
+// ListSessions returns the sessions of the log. Malformed lines are skipped
+// and reported once on stderr.
 func ListSessions() ([]Session, error) {
-	sessionPath, err := sessionPath()
+	sessions, err := index.Sessions()
 	if err != nil {
 		return nil, err
 	}
-
-	lines, err := ReadLines(sessionPath)
-	if err != nil {
-		return nil, err
-	}
-
-	sessions := make([]Session, 0, len(lines))
-	for _, line := range lines {
-		session := Session{}
-
-		if err := session.Scan(line); err != nil {
-			return nil, err
-		}
-
-		sessions = append(sessions, session)
-	}
+	index.report()
 
 	return sessions, nil
 }
@@ -1,8 +1,10 @@
 package pomo
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 )
 
 func Exec(command string) error {
@@ -18,3 +20,33 @@ func Exec(command string) error {
 
 	return nil
 }
+
+// execHook runs command like Exec but without standard input, which may
+// carry something else while hooks run, e.g. the protocol of `pomo rpc`.
+func execHook(command string) error {
+	cmd := exec.Command("bash", "-c", command)
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// ExecOutput runs command and returns its standard output without the
+// trailing newline. Standard error is included in the returned error.
+func ExecOutput(command string) (string, error) {
+	cmd := exec.Command("bash", "-c", command)
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", command, err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", command, err)
+	}
+
+	return strings.TrimRight(string(out), "\n"), nil
+}
@@ -0,0 +1,198 @@
+package pomo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// EventTick is the sound played every second of a work session when
+	// the ticking config is set.
+	EventTick = "tick"
+
+	// SoundVolume is the default playback volume, from 0 to 100.
+	SoundVolume = 80
+
+	soundDir   = "sounds"
+	sampleRate = 44100
+)
+
+// soundEvents are the events a sound can be configured for.
+var soundEvents = []string{EventWorkEnd, EventBreakEnd, EventWarning, EventTick}
+
+// players are tried in order when no sound_player is configured. {file} and
+// {volume} are replaced with the sound path and the volume scaled for the
+// player. aplay has no volume option, sound_volume is ignored with it.
+var players = []struct {
+	bin     string
+	command string
+	volume  func(v int) string
+}{
+	{"paplay", "paplay --volume={volume} {file}", func(v int) string { return strconv.Itoa(v * 65536 / 100) }},
+	{"pw-play", "pw-play --volume={volume} {file}", func(v int) string { return strconv.FormatFloat(float64(v)/100, 'f', 2, 64) }},
+	{"ffplay", "ffplay -nodisp -autoexit -loglevel quiet -volume {volume} {file}", strconv.Itoa},
+	{"aplay", "aplay -q {file}", func(int) string { return "" }},
+}
+
+// playSound plays the sound configured for event in the sounds config, e.g.
+//
+//	"sounds": {"work_end": "~/sounds/bell.wav", "warning": "~/sounds/soft.ogg"}
+//
+// Events without a sound use the bundled one. Sounds go through the
+// sound_player command or the first player found on the system, falling
+// back to the PowerShell beep under WSL for every event but the tick.
+func playSound(event string) error {
+	file, err := soundFile(event)
+	if err != nil {
+		return err
+	}
+
+	command := conf.QueryStringOr("sound_player", "")
+	volume := conf.QueryInt("sound_volume", SoundVolume)
+	scale := strconv.Itoa
+
+	if command == "" {
+		for _, p := range players {
+			if _, err := exec.LookPath(p.bin); err == nil {
+				command, scale = p.command, p.volume
+				break
+			}
+		}
+	}
+	if command == "" {
+		if _, err := exec.LookPath("pwsh.exe"); err == nil && event != EventTick {
+			return playAlert(event)
+		}
+		return fmt.Errorf("no sound player found, set sound_player in the config")
+	}
+
+	command = strings.NewReplacer(
+		"{file}", shellQuote(file),
+		"{volume}", scale(volume),
+	).Replace(command)
+
+	cmd := exec.Command("bash", "-c", command)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sound error: %v, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// soundFile returns the sound configured for event or the bundled default,
+// writing the bundled sounds to the config directory on first use.
+func soundFile(event string) (string, error) {
+	if sounds, ok := conf.Query("sounds").(map[string]interface{}); ok {
+		if file, ok := sounds[event].(string); ok && file != "" {
+			return expandHome(file), nil
+		}
+	}
+
+	name, synth := "default.wav", chime
+	switch event {
+	case EventTick:
+		name, synth = "tick.wav", click
+	case EventWarning:
+		name, synth = "warning.wav", soft
+	}
+
+	dir := filepath.Join(conf.DirPath(), soundDir)
+	path := filepath.Join(dir, name)
+	if Exists(path) {
+		return path, nil
+	}
+
+	if err := Mkdir(dir); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, wav(synth()), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// chime is the bundled alert, two rising notes fading out.
+func chime() []int16 {
+	return append(tone(880, 0.15), tone(1320, 0.35)...)
+}
+
+// soft is the bundled warning, a single low note quieter than the chime.
+func soft() []int16 {
+	samples := tone(660, 0.3)
+	for i := range samples {
+		samples[i] /= 3
+	}
+	return samples
+}
+
+// click is the bundled tick, a short blip.
+func click() []int16 {
+	return tone(2000, 0.015)
+}
+
+// tone synthesizes a sine wave of freq Hz fading out over seconds.
+func tone(freq, seconds float64) []int16 {
+	n := int(seconds * sampleRate)
+	samples := make([]int16, n)
+	for i := range samples {
+		t := float64(i) / sampleRate
+		fade := 1 - float64(i)/float64(n)
+		samples[i] = int16(math.Sin(2*math.Pi*freq*t) * fade * 0.6 * math.MaxInt16)
+	}
+	return samples
+}
+
+// wav encodes mono 16 bit PCM samples as a WAV file.
+func wav(samples []int16) []byte {
+	var buf bytes.Buffer
+	size := uint32(len(samples) * 2)
+
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, 36+size)
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, struct {
+		ChunkSize     uint32
+		Format        uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+	}{16, 1, 1, sampleRate, sampleRate * 2, 2, 16})
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, size)
+	binary.Write(&buf, binary.LittleEndian, samples)
+
+	return buf.Bytes()
+}
+
+// TestSound plays the sound of event.
+func TestSound(event string) error {
+	for _, e := range soundEvents {
+		if e == event {
+			return playSound(event)
+		}
+	}
+	return fmt.Errorf("unknown event %q, expected one of %s", event, strings.Join(soundEvents, ", "))
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homedir, path[2:])
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,19 @@
+package pomo
+
+import "testing"
+
+func TestWarningSoundIsSoft(t *testing.T) {
+	peak := func(samples []int16) int16 {
+		var p int16
+		for _, s := range samples {
+			if s > p {
+				p = s
+			}
+		}
+		return p
+	}
+
+	if soft, loud := peak(soft()), peak(chime()); soft*2 > loud {
+		t.Errorf("warning peaks at %d, want it well under the %d of the chime", soft, loud)
+	}
+}
@@ -0,0 +1,203 @@
+package pomo
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// StaleAfter is how long a session may overrun before it is stale.
+	StaleAfter = "30m"
+
+	// Policies to end stale sessions with, see the stale_policy config key.
+	RecoverPrompt   = "prompt"   // ask which of the others to use
+	RecoverPlanned  = "planned"  // end at start + duration
+	RecoverActivity = "activity" // end at the last editor activity
+	RecoverNow      = "now"      // end now
+)
+
+// isStale reports if the session is still running more than stale_after
+// past its planned end, usually because it was forgotten or the machine
+// went to sleep.
+func (s *Session) isStale() bool {
+	if s.ID == uuid.Nil || !s.isRunning() {
+		return false
+	}
+	threshold, err := time.ParseDuration(conf.QueryStringOr("stale_after", StaleAfter))
+	if err != nil {
+		return false
+	}
+	return s.Elapsed() < -threshold
+}
+
+// Recover ends a dangling session according to policy.
+func (s *Session) Recover(policy string) error {
+	return s.recoverBefore(policy, time.Time{})
+}
+
+// recoverBefore ends a dangling session according to policy, at the latest
+// at limit when it is after the start.
+func (s *Session) recoverBefore(policy string, limit time.Time) error {
+	if policy == RecoverPrompt {
+		policy = promptRecoverPolicy(*s)
+		if policy == "" {
+			return nil
+		}
+	}
+
+	end, err := recoverEnd(*s, policy)
+	if err != nil {
+		return err
+	}
+	if limit.After(s.StartTime) && end.After(limit) {
+		end = limit
+	}
+
+	s.EndTime = end
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	publish(EventStop, *s)
+
+	return nil
+}
+
+// RecoverSessions ends every dangling session in the log: running sessions
+// and timers that are stale, and sessions superseded by a later one. A
+// superseded session ends at the latest when the next one of the same name
+// starts. It returns the number of sessions recovered.
+func RecoverSessions(policy string) (int, error) {
+	sessions, err := ListSessions()
+	if err != nil {
+		return 0, err
+	}
+
+	// the start of the next session of the same name, zero for the last
+	next := make([]time.Time, len(sessions))
+	starts := map[string]time.Time{}
+	for i := len(sessions) - 1; i >= 0; i-- {
+		next[i] = starts[sessions[i].Name]
+		starts[sessions[i].Name] = sessions[i].StartTime
+	}
+
+	var recovered int
+	for i := range sessions {
+		s := &sessions[i]
+		superseded := !next[i].IsZero()
+		if !s.isRunning() || (!superseded && !s.isStale()) {
+			continue
+		}
+		if err := s.recoverBefore(policy, next[i]); err != nil {
+			return recovered, err
+		}
+		if !s.isRunning() {
+			recovered++
+		}
+	}
+
+	return recovered, nil
+}
+
+func recoverEnd(s Session, policy string) (time.Time, error) {
+	planned := s.plannedEnd()
+
+	switch policy {
+	case RecoverPlanned:
+		return planned, nil
+	case RecoverNow:
+		return clock.Now(), nil
+	case RecoverActivity:
+		if activity, ok := lastActivity(s); ok {
+			return activity, nil
+		}
+		return planned, nil
+	}
+
+	return time.Time{}, fmt.Errorf("unknown stale policy %q, expected %s, %s, %s or %s",
+		policy, RecoverPrompt, RecoverPlanned, RecoverActivity, RecoverNow)
+}
+
+// lastActivity returns the last time the editor reported a buffer during
+// the session.
+func lastActivity(s Session) (time.Time, bool) {
+	_, activity := activeFile()
+	if activity.IsZero() {
+		return time.Time{}, false
+	}
+
+	if activity.Before(s.StartTime) || activity.After(clock.Now()) {
+		return time.Time{}, false
+	}
+	return activity, true
+}
+
+func promptRecoverPolicy(s Session) string {
+	fmt.Printf("[WARNING]: The %s session started at %s overran by %s.\n",
+		s.Type, s.StartTime.Format("2006-01-02 15:04"), formatDurationHm(-s.Elapsed()))
+
+	answer := Input("End it at the [p]lanned end, last [a]ctivity, [n]ow or [k]eep it running? (p/a/n/k): ")
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "p", "planned":
+		return RecoverPlanned
+	case "a", "activity":
+		return RecoverActivity
+	case "n", "now":
+		return RecoverNow
+	}
+	return ""
+}
+
+// checkStale runs before every command and ends the current session if it
+// became stale. It only prompts when attached to a terminal, and never for
+// `print` which status bars run in a loop. Stale timers, which expired with
+// nothing watching them, are ended at their planned end without asking.
+func checkStale(cCtx *cli.Context) error {
+	switch cCtx.Args().First() {
+	case "init", "print", "sessions":
+		return nil
+	}
+	if !Exists(conf.DirPath()) {
+		return nil
+	}
+
+	timers, err := ListTimers()
+	if err != nil {
+		return err
+	}
+	for _, t := range timers {
+		if t.isStale() {
+			if err := t.Recover(RecoverPlanned); err != nil {
+				return err
+			}
+		}
+	}
+
+	policy := conf.QueryStringOr("stale_policy", RecoverPrompt)
+	if policy == RecoverPrompt && !isTerminal(os.Stdin) {
+		return nil
+	}
+
+	var session Session
+	if err := session.Get(); err != nil {
+		return err
+	}
+	if !session.isStale() {
+		return nil
+	}
+
+	return session.Recover(policy)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
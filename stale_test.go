@@ -0,0 +1,49 @@
+package pomo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRecoverSessions(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	now := day.Add(12 * time.Hour)
+
+	tests := []struct {
+		policy string
+		want   []time.Time // end of the first and the second session
+	}{
+		{RecoverNow, []time.Time{day.Add(9 * time.Hour), now}},
+		{RecoverPlanned, []time.Time{day.Add(8*time.Hour + 25*time.Minute), day.Add(9*time.Hour + 25*time.Minute)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			setupTest(t, now, nil)
+			writeSessions(t,
+				Session{ID: uuid.New(), StartTime: day.Add(8 * time.Hour), Duration: 25 * time.Minute, Type: WorkSession},
+				Session{ID: uuid.New(), StartTime: day.Add(9 * time.Hour), Duration: 25 * time.Minute, Type: WorkSession},
+			)
+
+			recovered, err := RecoverSessions(tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recovered != 2 {
+				t.Errorf("recovered %d sessions, want 2", recovered)
+			}
+
+			sessions, err := ListSessions()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, s := range sessions {
+				if !s.EndTime.Equal(tt.want[i]) {
+					t.Errorf("session %d ends at %s, want %s", i, s.EndTime.Format("15:04"), tt.want[i].Format("15:04"))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package pomo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// focusStats are the analytics of the work sessions between From and To.
+type focusStats struct {
+	From time.Time
+	To   time.Time
+
+	Focused   time.Duration // time spent in work sessions
+	Planned   time.Duration // of the finished sessions, time they were planned with
+	Extended  time.Duration // of the finished sessions, time they were extended by
+	Completed int           // work sessions that reached their planned end
+	Abandoned int           // work sessions stopped before their planned end
+	// AvgOverrun is the average time the completed sessions ran past their
+	// planned end.
+	AvgOverrun time.Duration
+	// InterruptionRate is the number of abandoned sessions per hour of
+	// focused work.
+	InterruptionRate float64
+	// Hours is the focused time per hour of the day.
+	Hours [24]time.Duration
+	// Score is the focus score, see focusScore.
+	Score int
+}
+
+// computeStats returns the analytics of the pomodoros between from and to.
+// Named timers are left out. Running sessions count toward the focused time
+// but are neither completed nor abandoned yet.
+func computeStats(sessions []Session, from, to time.Time) focusStats {
+	stats := focusStats{From: from, To: to}
+
+	var overrun time.Duration
+	for _, s := range filterSessions(sessions, from, to) {
+		if s.Type != WorkSession || s.Name != "" {
+			continue
+		}
+
+		stats.Focused += overlap(s, from, to)
+		for hour := range stats.Hours {
+			stats.Hours[hour] += hourOverlap(s, from, to, hour)
+		}
+
+		// the outcome counts on the day the session started
+		if s.isRunning() || s.StartTime.Before(from) {
+			continue
+		}
+		planned, extended := s.splitTime()
+		stats.Planned += planned
+		stats.Extended += extended
+		if !s.isCompleted() {
+			stats.Abandoned++
+			continue
+		}
+		stats.Completed++
+		overrun += s.EndTime.Sub(s.plannedEnd())
+	}
+
+	if stats.Completed > 0 {
+		stats.AvgOverrun = overrun / time.Duration(stats.Completed)
+	}
+	if stats.Focused > 0 {
+		stats.InterruptionRate = float64(stats.Abandoned) / stats.Focused.Hours()
+	}
+	stats.Score = focusScore(stats, workGoal(from, to), workDuration())
+
+	return stats
+}
+
+// focusScore rates the focus of a period from 0 to 100:
+//
+//	score = 100 × (0.5 × completion + 0.3 × goal + 0.2 × punctuality)
+//
+// where completion is the share of the finished pomodoros that were
+// completed rather than abandoned, goal is the focused time over the work
+// goal of the period capped at 1, and punctuality is 1 minus the average
+// overrun over the pomodoro duration, floored at 0. A period without any
+// finished pomodoro has a completion and punctuality of 0.
+func focusScore(stats focusStats, goal, pomodoro time.Duration) int {
+	var completion, punctuality float64
+	if finished := stats.Completed + stats.Abandoned; finished > 0 {
+		completion = float64(stats.Completed) / float64(finished)
+		punctuality = math.Max(0, 1-float64(stats.AvgOverrun)/float64(pomodoro))
+	}
+
+	var reached float64
+	if goal > 0 {
+		reached = math.Min(1, float64(stats.Focused)/float64(goal))
+	}
+
+	return int(math.Round(100 * (0.5*completion + 0.3*reached + 0.2*punctuality)))
+}
+
+// hourOverlap returns how much of the session fell in the given hour of the
+// day within from and to.
+func hourOverlap(s Session, from, to time.Time, hour int) time.Duration {
+	start, end := s.StartTime, sessionEnd(s)
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+
+	var total time.Duration
+	y, m, d := start.Date()
+	for day := time.Date(y, m, d, 0, 0, 0, 0, start.Location()); day.Before(end); day = day.AddDate(0, 0, 1) {
+		h := time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, day.Location())
+		total += overlapRange(start, end, h, h.Add(time.Hour))
+	}
+	return total
+}
+
+// weekBounds returns the start and end of the week, starting on Monday,
+// containing t.
+func weekBounds(t time.Time) (time.Time, time.Time) {
+	from, _ := dayBounds(t)
+	offset := (int(from.Weekday()) + 6) % 7
+
+	y, m, d := from.Date()
+	from = time.Date(y, m, d-offset, from.Hour(), from.Minute(), 0, 0, from.Location())
+	to := time.Date(y, m, d-offset+7, from.Hour(), from.Minute(), 0, 0, from.Location())
+	return from, to
+}
+
+// workGoal returns the work goal of the days between from and to.
+func workGoal(from, to time.Time) time.Duration {
+	days := int(math.Round(to.Sub(from).Hours() / 24))
+	if days < 1 {
+		days = 1
+	}
+	return time.Duration(days) * WorkGoal
+}
+
+// workDuration returns the configured duration of a pomodoro.
+func workDuration() time.Duration {
+	d, err := time.ParseDuration(conf.QueryStringOr("duration", Duration))
+	if err != nil || d <= 0 {
+		d, _ = time.ParseDuration(Duration)
+	}
+	return d
+}
+
+// Stats prints the analytics of the day of t and of its week.
+func Stats(t time.Time) error {
+	sessions, err := ListSessions()
+	if err != nil {
+		return err
+	}
+
+	today, week, days := weekStats(sessions, t)
+
+	fmt.Printf("%-10s %5s %9s %9s %9s %9s %8s %8s %5s\n",
+		"DAY", "SCORE", "FOCUSED", "PLANNED", "EXTENDED", "COMPLETED", "ABANDON", "OVERRUN", "INT/H")
+	for _, day := range days {
+		printStatsRow(day.From.Format("Mon 01-02"), day)
+	}
+	printStatsRow("week", week)
+
+	fmt.Printf("\nFocus by hour, %s\n", today.From.Format("Mon 2006-01-02"))
+	fmt.Println(hoursChart(today.Hours))
+	fmt.Printf("\nFocus by hour, week of %s\n", week.From.Format("2006-01-02"))
+	fmt.Println(hoursChart(week.Hours))
+
+	return nil
+}
+
+// weekStats returns the analytics of the day of t, of its week and of every
+// day of the week.
+func weekStats(sessions []Session, t time.Time) (focusStats, focusStats, []focusStats) {
+	weekFrom, weekTo := weekBounds(t)
+
+	var days []focusStats
+	for day := weekFrom; day.Before(weekTo); {
+		_, next := dayBounds(day)
+		days = append(days, computeStats(sessions, day, next))
+		day = next
+	}
+
+	dayFrom, dayTo := dayBounds(t)
+	return computeStats(sessions, dayFrom, dayTo), computeStats(sessions, weekFrom, weekTo), days
+}
+
+func printStatsRow(label string, s focusStats) {
+	fmt.Printf("%-10s %5d %9s %9s %9s %9d %8d %8s %5.1f\n",
+		label, s.Score, formatDurationHm(s.Focused), formatDurationHm(s.Planned), formatDurationHm(s.Extended),
+		s.Completed, s.Abandoned, formatDurationHm(s.AvgOverrun), s.InterruptionRate)
+}
+
+// hoursChart draws the focus per hour of the day as a sparkline with the
+// hours below it.
+func hoursChart(hours [24]time.Duration) string {
+	values := make([]float64, len(hours))
+	for i, d := range hours {
+		values[i] = d.Minutes()
+	}
+
+	var axis strings.Builder
+	for hour := 0; hour < 24; hour += 6 {
+		axis.WriteString(fmt.Sprintf("%-6d", hour))
+	}
+
+	return workStyle.Render(sparkline(values)) + "\n" + helpStyle.Render(axis.String())
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws the values as a line of block characters, scaled to the
+// largest one. Zero values are left blank.
+func sparkline(values []float64) string {
+	var max float64
+	for _, v := range values {
+		max = math.Max(max, v)
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		if v <= 0 || max == 0 {
+			sb.WriteRune(' ')
+			continue
+		}
+		i := int(math.Ceil(v/max*float64(len(sparkBlocks)))) - 1
+		sb.WriteRune(sparkBlocks[i])
+	}
+	return sb.String()
+}
+
+// viewStats renders the analytics page of the status TUI.
+func viewStats(day, week focusStats, days []focusStats) string {
+	scores := make([]float64, len(days))
+	focused := make([]float64, len(days))
+	for i, d := range days {
+		scores[i] = float64(d.Score)
+		focused[i] = d.Focused.Minutes()
+	}
+
+	summary := func(title string, s focusStats) string {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			title,
+			workStyle.Render(fmt.Sprintf("Focus score: %d", s.Score)),
+			fmt.Sprintf("Focused:     %s", formatDurationHm(s.Focused)),
+			fmt.Sprintf("Extended:    %s", formatDurationHm(s.Extended)),
+			fmt.Sprintf("Completed:   %d", s.Completed),
+			fmt.Sprintf("Abandoned:   %d", s.Abandoned),
+			fmt.Sprintf("Avg overrun: %s", formatDurationHm(s.AvgOverrun)),
+			fmt.Sprintf("Interrupts:  %.1f/h", s.InterruptionRate),
+		)
+	}
+
+	trend := lipgloss.JoinVertical(lipgloss.Left,
+		"Week trend",
+		"Score   "+workStyle.Render(sparkline(scores)),
+		"Focused "+breakStyle.Render(sparkline(focused)),
+		helpStyle.Render("        MTWTFSS"),
+	)
+
+	return lipgloss.JoinVertical(lipgloss.Center,
+		lipgloss.JoinHorizontal(lipgloss.Top,
+			sectionStyle.Render(summary("Day", day)),
+			"  ",
+			sectionStyle.Render(summary("Week", week)),
+			"  ",
+			sectionStyle.Render(trend),
+		),
+		sectionStyle.Render(lipgloss.JoinVertical(lipgloss.Center, "Focus by hour", hoursChart(day.Hours))),
+	)
+}
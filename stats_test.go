@@ -0,0 +1,195 @@
+package pomo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// work returns a work session of 25 minutes started at start that ran for
+// ran, still running when ran is 0.
+func work(start time.Time, ran time.Duration) Session {
+	s := Session{ID: uuid.New(), StartTime: start, Duration: 25 * time.Minute, Type: WorkSession}
+	if ran > 0 {
+		s.EndTime = start.Add(ran)
+	}
+	return s
+}
+
+func TestComputeStats(t *testing.T) {
+	utc := time.UTC
+	// a Tuesday
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, utc)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	tests := []struct {
+		name      string
+		dayStart  string
+		now       time.Time
+		sessions  []Session
+		focused   time.Duration
+		completed int
+		abandoned int
+		overrun   time.Duration
+		extended  time.Duration
+	}{
+		{
+			name:      "empty day",
+			now:       at(12, 0),
+			sessions:  nil,
+			focused:   0,
+			completed: 0,
+		},
+		{
+			name: "completed, abandoned and running",
+			now:  at(12, 0),
+			sessions: []Session{
+				work(at(9, 0), 27*time.Minute),
+				work(at(10, 0), 10*time.Minute),
+				{ID: uuid.New(), StartTime: at(10, 30), EndTime: at(10, 35), Duration: 5 * time.Minute, Type: BreakSession},
+				{ID: uuid.New(), Name: "tea", StartTime: at(10, 40), EndTime: at(10, 45), Duration: 5 * time.Minute, Type: WorkSession},
+				work(at(11, 50), 0),
+			},
+			focused:   47 * time.Minute,
+			completed: 1,
+			abandoned: 1,
+			overrun:   2 * time.Minute,
+		},
+		{
+			name: "extended",
+			now:  at(12, 0),
+			sessions: []Session{
+				{ID: uuid.New(), StartTime: at(9, 0), EndTime: at(9, 30), Duration: 30 * time.Minute, Type: WorkSession,
+					Adjustments: []time.Duration{5 * time.Minute}},
+			},
+			focused:   30 * time.Minute,
+			completed: 1,
+			extended:  5 * time.Minute,
+		},
+		{
+			name: "across midnight counts the outcome on the first day",
+			now:  at(23, 59),
+			sessions: []Session{
+				work(at(-1, 50), 25*time.Minute),
+				work(at(23, 50), 25*time.Minute),
+			},
+			focused:   25 * time.Minute,
+			completed: 1,
+		},
+		{
+			name:     "late sessions belong to the day before day_start",
+			dayStart: "04:00",
+			now:      at(12, 0),
+			sessions: []Session{
+				work(at(2, 0), 25*time.Minute),
+				work(at(26, 0), 25*time.Minute),
+			},
+			focused:   25 * time.Minute,
+			completed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{}
+			if tt.dayStart != "" {
+				config["day_start"] = tt.dayStart
+			}
+			setupTest(t, tt.now, config)
+
+			from, to := dayBounds(tt.now)
+			got := computeStats(tt.sessions, from, to)
+
+			if got.Focused != tt.focused || got.Completed != tt.completed || got.Abandoned != tt.abandoned {
+				t.Errorf("focused %s, completed %d, abandoned %d, want %s, %d, %d",
+					got.Focused, got.Completed, got.Abandoned, tt.focused, tt.completed, tt.abandoned)
+			}
+			if got.AvgOverrun != tt.overrun || got.Extended != tt.extended {
+				t.Errorf("overrun %s, extended %s, want %s, %s", got.AvgOverrun, got.Extended, tt.overrun, tt.extended)
+			}
+			if len(tt.sessions) == 0 && got.Score != 0 {
+				t.Errorf("score of an empty day = %d, want 0", got.Score)
+			}
+		})
+	}
+}
+
+func TestWeekStats(t *testing.T) {
+	utc := time.UTC
+	// Monday 2024-01-01 to Sunday 2024-01-07
+	monday := time.Date(2024, 1, 1, 0, 0, 0, 0, utc)
+	sessions := []Session{
+		// Sunday night of the week before
+		work(monday.Add(-time.Hour), 25*time.Minute),
+		// Monday night, before a 04:00 day_start
+		work(monday.Add(2*time.Hour), 25*time.Minute),
+		work(monday.Add(10*time.Hour), 25*time.Minute),
+		// Wednesday
+		work(monday.Add(2*24*time.Hour+9*time.Hour), 10*time.Minute),
+		// Sunday night, after midnight of the next week
+		work(monday.Add(7*24*time.Hour+time.Hour), 25*time.Minute),
+	}
+
+	tests := []struct {
+		name     string
+		dayStart string
+		at       time.Time
+		from     time.Time
+		today    int // completed pomodoros of the day of at
+		week     int // completed pomodoros of the week
+		monday   int // completed pomodoros of the Monday
+	}{
+		{
+			name:   "midnight",
+			at:     monday.Add(2*24*time.Hour + 12*time.Hour),
+			from:   monday,
+			today:  0,
+			week:   2,
+			monday: 2,
+		},
+		{
+			name:     "day_start",
+			dayStart: "04:00",
+			at:       monday.Add(2*24*time.Hour + 12*time.Hour),
+			from:     monday.Add(4 * time.Hour),
+			today:    0,
+			week:     2,
+			monday:   1,
+		},
+		{
+			name:     "early Monday is still the week before",
+			dayStart: "04:00",
+			at:       monday.Add(3 * time.Hour),
+			from:     monday.Add(-7*24*time.Hour + 4*time.Hour),
+			today:    2,
+			week:     2,
+			monday:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{}
+			if tt.dayStart != "" {
+				config["day_start"] = tt.dayStart
+			}
+			setupTest(t, tt.at, config)
+
+			today, week, days := weekStats(sessions, tt.at)
+
+			if !week.From.Equal(tt.from) || !week.To.Equal(tt.from.AddDate(0, 0, 7)) {
+				t.Errorf("week from %v to %v, want from %v", week.From, week.To, tt.from)
+			}
+			if len(days) != 7 || !days[0].From.Equal(tt.from) || days[0].From.Weekday() != time.Monday {
+				t.Fatalf("days = %d starting %v, want 7 from %v", len(days), days[0].From, tt.from)
+			}
+			if today.Completed != tt.today || week.Completed != tt.week || days[0].Completed != tt.monday {
+				t.Errorf("completed today %d, in the week %d, on Monday %d, want %d, %d, %d",
+					today.Completed, week.Completed, days[0].Completed, tt.today, tt.week, tt.monday)
+			}
+		})
+	}
+}
@@ -10,17 +10,35 @@ import (
 )
 
 type statusModel struct {
-	workDuration   time.Duration
-	breakDuration  time.Duration
-	workGoal       time.Duration
-	restGoal       time.Duration
-	workPercentage float64
-	restPercentage float64
-	quit           bool
-	width          int
-	height         int
+	sessions  []Session
+	now       time.Time
+	malformed int // lines of the session log that were skipped
+	quit      bool
+	width     int
+	height    int
+
+	// tab is the period shown, switched with tab or 1 to 4
+	tab int
+	// offset is how many periods before the current one are shown, moved
+	// with h and l
+	offset int
+
+	// analytics of the Focus tab, computed in Update as the log is read
+	focusDay, focusWeek focusStats
+	focusDays           []focusStats
 }
 
+// Tabs of the status TUI.
+const (
+	statusDay = iota
+	statusWeek
+	statusMonth
+	statusFocus
+	statusTabs
+)
+
+var statusTabNames = []string{"Day", "Week", "Month", "Focus"}
+
 var (
 	sectionStyle = lipgloss.NewStyle().
 			BorderStyle(lipgloss.RoundedBorder()).
@@ -40,25 +58,21 @@ var (
 			Foreground(lipgloss.Color("#666666")).
 			Align(lipgloss.Center)
 
+	activeTabStyle = lipgloss.NewStyle().
+			Bold(true).
+			Padding(0, 1).
+			Underline(true)
+
+	tabStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#666666")).
+			Padding(0, 1)
+
 	progressBarWidth = 30
+	chartBarWidth    = 30
 )
 
 func renderProgressBar(percentage float64, width int) string {
-	filled := int(percentage * float64(width) / 100)
-	if filled > width {
-		filled = width
-	}
-
-	bar := ""
-	for i := 0; i < width; i++ {
-		if i < filled {
-			bar += "━"
-		} else {
-			bar += "─"
-		}
-	}
-
-	return fmt.Sprintf("[%s] %.1f%%", bar, percentage)
+	return fmt.Sprintf("[%s] %.1f%%", progressBar(percentage, width), percentage)
 }
 
 func (m statusModel) Init() tea.Cmd {
@@ -72,32 +86,70 @@ func (m statusModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "q", "ctrl+c", "esc":
 			m.quit = true
 			return m, tea.Quit
+		case "tab":
+			m.tab = (m.tab + 1) % statusTabs
+			m.offset = 0
+		case "shift+tab":
+			m.tab = (m.tab + statusTabs - 1) % statusTabs
+			m.offset = 0
+		case "1", "2", "3", "4":
+			m.tab = int(msg.String()[0] - '1')
+			m.offset = 0
+		case "h", "left":
+			m.offset++
+		case "l", "right":
+			if m.offset > 0 {
+				m.offset--
+			}
 		}
+		m.updateFocus()
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 	case time.Time:
-		// Update statistics
-		sessions, err := ListSessions()
+		// Update statistics, only the changes of the log are parsed
+		sessions, err := index.Sessions()
 		if err != nil {
 			return m, tick()
 		}
+		m.sessions = sessions
+		m.malformed = len(index.Malformed())
+		m.now = msg
+		m.updateFocus()
 
-		todaySessions, err := filterTodaySessions(sessions)
-		if err != nil {
-			return m, tick()
-		}
+		return m, tick()
+	}
+	return m, nil
+}
 
-		typeDurations, _ := summarizeSessions(todaySessions)
+// updateFocus computes the analytics of the Focus tab when it is shown.
+func (m *statusModel) updateFocus() {
+	if m.tab != statusFocus {
+		return
+	}
+	m.focusDay, m.focusWeek, m.focusDays = weekStats(m.sessions, m.anchor())
+}
 
-		m.workDuration = typeDurations[WorkSession]
-		m.breakDuration = typeDurations[BreakSession]
-		m.workPercentage = float64(m.workDuration) / float64(WorkGoal) * 100
-		m.restPercentage = float64(m.breakDuration) / float64(RestGoal) * 100
+// anchor returns a time within the period shown, offset periods back from
+// the current one.
+func (m statusModel) anchor() time.Time {
+	if m.offset == 0 {
+		return m.now
+	}
 
-		return m, tick()
+	today, _ := dayBounds(m.now)
+	y, mo, d := today.Date()
+	switch m.tab {
+	case statusMonth:
+		mo -= time.Month(m.offset)
+		d = 1
+	case statusWeek, statusFocus:
+		d -= 7 * m.offset
+	default:
+		d -= m.offset
 	}
-	return m, nil
+	// noon is always within the day, whatever the day_start
+	return time.Date(y, mo, d, 12, 0, 0, 0, m.now.Location())
 }
 
 func (m statusModel) View() string {
@@ -105,14 +157,47 @@ func (m statusModel) View() string {
 		return ""
 	}
 
+	var content string
+	switch m.tab {
+	case statusWeek:
+		content = m.viewWeek()
+	case statusMonth:
+		content = m.viewMonth()
+	case statusFocus:
+		content = viewStats(m.focusDay, m.focusWeek, m.focusDays)
+	default:
+		content = m.viewDay()
+	}
+
+	tabs := make([]string, len(statusTabNames))
+	for i, name := range statusTabNames {
+		if i == m.tab {
+			tabs[i] = activeTabStyle.Render(name)
+		} else {
+			tabs[i] = tabStyle.Render(name)
+		}
+	}
+
+	help := "tab/1-4: view • h/l: previous/next • q: quit"
+	if m.malformed > 0 {
+		help = fmt.Sprintf("%d malformed sessions skipped, see pomo sessions check • %s", m.malformed, help)
+	}
+
+	page := lipgloss.JoinVertical(lipgloss.Center,
+		lipgloss.JoinHorizontal(lipgloss.Top, tabs...),
+		"",
+		content,
+		"",
+		helpStyle.Render(help),
+	)
+
 	// Calculate vertical padding
-	verticalPad := (m.height - 8) / 2
+	verticalPad := (m.height - lipgloss.Height(page)) / 2
 	if verticalPad < 0 {
 		verticalPad = 0
 	}
 
 	doc := strings.Builder{}
-
 	if verticalPad > 0 {
 		doc.WriteString(strings.Repeat("\n", verticalPad))
 	}
@@ -121,62 +206,158 @@ func (m statusModel) View() string {
 		Width(m.width).
 		Align(lipgloss.Center)
 
+	doc.WriteString(containerStyle.Render(page))
+
+	return doc.String()
+}
+
+// viewDay shows the work and break of a day against the daily goals.
+func (m statusModel) viewDay() string {
+	from, to := dayBounds(m.anchor())
+	workDuration, breakDuration := m.daySummary(from, to)
+	workPercentage := float64(workDuration) / float64(WorkGoal) * 100
+	restPercentage := float64(breakDuration) / float64(RestGoal) * 100
+
+	title := "Today's Sessions"
+	if m.offset > 0 {
+		title = from.Format("Monday 2006-01-02")
+	}
+
 	// Sessions Section
 	sessionsContent := lipgloss.JoinVertical(lipgloss.Center,
-		"Today's Sessions",
-		workStyle.Render(fmt.Sprintf("Work:  %s", formatDurationHm(m.workDuration))),
-		breakStyle.Render(fmt.Sprintf("Break: %s", formatDurationHm(m.breakDuration))),
+		title,
+		workStyle.Render(fmt.Sprintf("Work:  %s", formatDurationHm(workDuration))),
+		breakStyle.Render(fmt.Sprintf("Break: %s", formatDurationHm(breakDuration))),
 	)
 
 	// Goals Section
-	workProgress := renderProgressBar(m.workPercentage, progressBarWidth)
-	restProgress := renderProgressBar(m.restPercentage, progressBarWidth)
+	workProgress := renderProgressBar(workPercentage, progressBarWidth)
+	restProgress := renderProgressBar(restPercentage, progressBarWidth)
 
 	goalsContent := lipgloss.JoinVertical(lipgloss.Center,
 		"Daily Goals",
-		workStyle.Render(fmt.Sprintf("Work:  %s", formatDurationHm(m.workGoal))),
+		workStyle.Render(fmt.Sprintf("Work:  %s", formatDurationHm(WorkGoal))),
 		workStyle.Render(workProgress),
-		breakStyle.Render(fmt.Sprintf("Break: %s", formatDurationHm(m.restGoal))),
+		breakStyle.Render(fmt.Sprintf("Break: %s", formatDurationHm(RestGoal))),
 		breakStyle.Render(restProgress),
 	)
 
-	sections := lipgloss.JoinHorizontal(
+	return lipgloss.JoinHorizontal(
 		lipgloss.Center,
 		sectionStyle.Render(sessionsContent),
 		"    ",
 		sectionStyle.Render(goalsContent),
 	)
+}
 
-	doc.WriteString(containerStyle.Render(sections))
-	doc.WriteString("\n\n")
-	doc.WriteString(containerStyle.Render(helpStyle.Render("Press 'q' to quit")))
+// viewWeek draws a bar chart of the work and break of every day of a week.
+// Bars are scaled to the daily work goal, or to the longest day when it
+// went past the goal.
+func (m statusModel) viewWeek() string {
+	weekFrom, weekTo := weekBounds(m.anchor())
 
-	return doc.String()
+	type day struct {
+		from         time.Time
+		work, breaks time.Duration
+	}
+	var days []day
+	scale := WorkGoal
+	var totalWork, totalBreak time.Duration
+	for from := weekFrom; from.Before(weekTo); {
+		_, to := dayBounds(from)
+		work, breaks := m.daySummary(from, to)
+		days = append(days, day{from, work, breaks})
+		totalWork += work
+		totalBreak += breaks
+		if work > scale {
+			scale = work
+		}
+		if breaks > scale {
+			scale = breaks
+		}
+		from = to
+	}
+
+	bar := func(d time.Duration) string {
+		n := int(float64(d) / float64(scale) * float64(chartBarWidth))
+		if n == 0 && d > 0 {
+			n = 1
+		}
+		return strings.Repeat("█", n) + strings.Repeat(" ", chartBarWidth-n)
+	}
+
+	lines := []string{fmt.Sprintf("Week of %s", weekFrom.Format("2006-01-02")), ""}
+	for _, d := range days {
+		lines = append(lines,
+			fmt.Sprintf("%s %s %7s", d.from.Format("Mon"), workStyle.Render(bar(d.work)), formatDurationHm(d.work)),
+			fmt.Sprintf("    %s %7s", breakStyle.Render(bar(d.breaks)), formatDurationHm(d.breaks)),
+		)
+	}
+	lines = append(lines, "",
+		workStyle.Render(fmt.Sprintf("Work:  %s", formatDurationHm(totalWork)))+"   "+
+			breakStyle.Render(fmt.Sprintf("Break: %s", formatDurationHm(totalBreak))),
+	)
+
+	return sectionStyle.Align(lipgloss.Left).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
 }
 
-func ShowStatus() error {
-	sessions, err := ListSessions()
-	if err != nil {
-		return err
+// viewMonth draws a calendar of a month where the days that met the daily
+// work goal are marked with ✓ and the days with some work with ·.
+func (m statusModel) viewMonth() string {
+	anchor := m.anchor()
+	y, mo, _ := anchor.Date()
+	first := time.Date(y, mo, 1, 12, 0, 0, 0, anchor.Location())
+	_, todayEnd := dayBounds(m.now)
+
+	var sb strings.Builder
+	sb.WriteString(first.Format("January 2006") + "\n\n")
+	sb.WriteString(helpStyle.Render(" Mo  Tu  We  Th  Fr  Sa  Su ") + "\n")
+
+	met, worked := 0, 0
+	var totalWork time.Duration
+	sb.WriteString(strings.Repeat("    ", (int(first.Weekday())+6)%7))
+	for day := first; day.Month() == mo; day = time.Date(y, mo, day.Day()+1, 12, 0, 0, 0, day.Location()) {
+		from, to := dayBounds(day)
+		work, _ := m.daySummary(from, to)
+		totalWork += work
+
+		cell := fmt.Sprintf("%3d", day.Day())
+		switch {
+		case work >= WorkGoal:
+			cell = workStyle.Render(cell + "✓")
+			met++
+			worked++
+		case work > 0:
+			cell = breakStyle.Render(cell + "·")
+			worked++
+		case !from.Before(todayEnd):
+			cell = helpStyle.Render(cell + " ")
+		default:
+			cell += " "
+		}
+		sb.WriteString(cell)
+
+		if day.Weekday() == time.Sunday {
+			sb.WriteString("\n")
+		}
 	}
 
-	todaySessions, err := filterTodaySessions(sessions)
+	sb.WriteString(fmt.Sprintf("\n\nGoal met on %d of %d days worked\n", met, worked))
+	sb.WriteString(workStyle.Render(fmt.Sprintf("Work: %s", formatDurationHm(totalWork))))
+
+	return sectionStyle.Align(lipgloss.Left).Render(sb.String())
+}
+
+func ShowStatus() error {
+	sessions, err := ListSessions()
 	if err != nil {
 		return err
 	}
 
-	typeDurations, _ := summarizeSessions(todaySessions)
-
-	workDuration := typeDurations[WorkSession]
-	breakDuration := typeDurations[BreakSession]
-
 	model := statusModel{
-		workDuration:   workDuration,
-		breakDuration:  breakDuration,
-		workGoal:       WorkGoal,
-		restGoal:       RestGoal,
-		workPercentage: float64(workDuration) / float64(WorkGoal) * 100,
-		restPercentage: float64(breakDuration) / float64(RestGoal) * 100,
+		sessions:  sessions,
+		now:       clock.Now(),
+		malformed: len(index.Malformed()),
 	}
 
 	p := tea.NewProgram(
@@ -191,34 +372,47 @@ func ShowStatus() error {
 	return nil
 }
 
+// daySummary returns the work and break time of the day between from and
+// to, cached by the session index.
+func (m statusModel) daySummary(from, to time.Time) (time.Duration, time.Duration) {
+	work, pause, err := index.DaySummary(from, to)
+	if err != nil {
+		return 0, 0
+	}
+	return work, pause
+}
+
+// filterTodaySessions returns the sessions that overlap with today.
 func filterTodaySessions(sessions []Session) ([]Session, error) {
-	var todaySessions []Session
-	now := time.Now()
-	today := now.Format("2006-01-02") // YYYY-MM-DD format
+	from, to := dayBounds(clock.Now())
+	return filterSessions(sessions, from, to), nil
+}
 
+// filterSessions returns the sessions that overlap with [from, to).
+func filterSessions(sessions []Session, from, to time.Time) []Session {
+	var filtered []Session
 	for _, session := range sessions {
-		if session.StartTime.Format("2006-01-02") == today {
-			todaySessions = append(todaySessions, session)
+		if session.StartTime.Before(to) && sessionEnd(session).After(from) {
+			filtered = append(filtered, session)
 		}
 	}
-
-	return todaySessions, nil
+	return filtered
 }
 
-func summarizeSessions(sessions []Session) (map[SessionType]time.Duration, map[string]time.Duration) {
+// summarizeSessions sums the time spent per session type and per file
+// between from and to. Sessions crossing the bounds only count the part
+// inside them, and the time per file follows the focus changes of each
+// session.
+func summarizeSessions(sessions []Session, from, to time.Time) (map[SessionType]time.Duration, map[string]time.Duration) {
 	typeDurations := make(map[SessionType]time.Duration)
 	projectDurations := make(map[string]time.Duration)
 
 	for _, session := range sessions {
-		var duration time.Duration
-		if !session.EndTime.IsZero() {
-			duration = session.EndTime.Sub(session.StartTime)
-		} else {
-			duration = time.Since(session.StartTime)
-		}
+		typeDurations[session.Type] += overlap(session, from, to)
 
-		typeDurations[session.Type] += duration
-		projectDurations[session.File] += duration
+		for _, segment := range session.Segments() {
+			projectDurations[segment.File] += overlapRange(segment.From, segment.To, from, to)
+		}
 	}
 
 	return typeDurations, projectDurations
@@ -0,0 +1,189 @@
+package pomo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Status bar output modes of `pomo print`.
+const (
+	BarWaybar   = "waybar"
+	BarI3blocks = "i3blocks"
+	BarPolybar  = "polybar"
+	BarTmux     = "tmux"
+)
+
+// barColors maps a print class to the hex colour used by status bars.
+var barColors = map[string]string{
+	"idle":      "#666666",
+	"work":      "#98C379",
+	"break":     "#61AFEF",
+	"longbreak": "#61AFEF",
+	"warn":      "#E5C07B",
+	"overrun":   "#E06C75",
+}
+
+// tmuxColors maps a print class to a tmux colour, matching the TUI.
+var tmuxColors = map[string]string{
+	"idle":      "colour240",
+	"work":      "colour35",
+	"break":     "colour39",
+	"longbreak": "colour39",
+	"warn":      "colour214",
+	"overrun":   "colour196",
+}
+
+type waybarOutput struct {
+	Text       string `json:"text"`
+	Tooltip    string `json:"tooltip"`
+	Class      string `json:"class"`
+	Percentage int    `json:"percentage"`
+}
+
+type i3blocksOutput struct {
+	FullText  string `json:"full_text"`
+	ShortText string `json:"short_text"`
+	Color     string `json:"color"`
+}
+
+// PrintBar writes the current session formatted for the given status bar.
+// With follow set it keeps running and writes one update per line every
+// second, so bars can read it as a persistent script.
+func PrintBar(mode, format, idle string, follow bool) error {
+	if !follow {
+		out, err := renderBar(mode, format, idle, false)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+		return nil
+	}
+
+	// a broken template never recovers, everything else is retried
+	for _, f := range []string{format, idle} {
+		if _, err := template.New("print").Funcs(printFuncs).Parse(f); err != nil {
+			return fmt.Errorf("invalid print format: %w", err)
+		}
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var last, lastErr string
+	for {
+		out, err := renderBar(mode, format, idle, true)
+		switch {
+		case err != nil:
+			// the session log is rewritten under us now and then, keep
+			// polling and only report an error once until it changes
+			if err.Error() != lastErr {
+				fmt.Fprintf(os.Stderr, "print: %v\n", err)
+				lastErr = err.Error()
+			}
+		case out != last:
+			// only write when something changed, bars redraw on every line
+			fmt.Println(out)
+			last, lastErr = out, ""
+		default:
+			lastErr = ""
+		}
+		<-ticker.C
+	}
+}
+
+func renderBar(mode, format, idle string, follow bool) (string, error) {
+	data, err := currentPrintData()
+	if err != nil {
+		return "", err
+	}
+
+	text, err := renderPrint(data, format, idle)
+	if err != nil {
+		return "", err
+	}
+
+	switch mode {
+	case BarWaybar:
+		percentage := int(data.Percent)
+		if percentage > 100 {
+			percentage = 100
+		}
+		buf, err := json.Marshal(waybarOutput{
+			Text:       text,
+			Tooltip:    barTooltip(data),
+			Class:      data.Class,
+			Percentage: percentage,
+		})
+		if err != nil {
+			return "", err
+		}
+		if follow {
+			return string(buf), nil
+		}
+		return string(buf) + "\n", nil
+
+	case BarI3blocks:
+		short := StopWatchFormat(data.Remaining)
+		if !data.Running {
+			short = text
+		}
+		// persistent blocks read one JSON object per line
+		if follow {
+			buf, err := json.Marshal(i3blocksOutput{
+				FullText:  text,
+				ShortText: short,
+				Color:     barColors[data.Class],
+			})
+			if err != nil {
+				return "", err
+			}
+			return string(buf), nil
+		}
+		return fmt.Sprintf("%s\n%s\n%s\n", text, short, barColors[data.Class]), nil
+
+	case BarPolybar:
+		// left click stops the running session or starts a new one
+		action := "pomo stop"
+		if !data.Running {
+			action = "pomo"
+		}
+		return fmt.Sprintf("%%{A1:%s:}%%{F%s}%s%%{F-}%%{A}",
+			action, barColors[data.Class], escapePolybar(text)), nil
+
+	case BarTmux:
+		return fmt.Sprintf("#[fg=%s]%s#[default]",
+			tmuxColors[data.Class], strings.ReplaceAll(text, "#", "##")), nil
+	}
+
+	return text, nil
+}
+
+func barTooltip(data printData) string {
+	if !data.Running {
+		return "No session running"
+	}
+
+	var sb strings.Builder
+	if data.Type == WorkSession {
+		sb.WriteString(fmt.Sprintf("Work session #%d", data.Cycle))
+	} else {
+		sb.WriteString("Break session")
+	}
+	if data.Task != "" {
+		sb.WriteString(": " + data.Task)
+	}
+	sb.WriteString(fmt.Sprintf("\n%s of %s", formatDurationHm(data.Elapsed), formatDurationHm(data.Duration)))
+	if data.Overrun {
+		sb.WriteString(fmt.Sprintf(" (overrun by %s)", StopWatchFormat(-data.Remaining)))
+	}
+	return sb.String()
+}
+
+// escapePolybar escapes the characters polybar reads as format tags.
+func escapePolybar(text string) string {
+	return strings.ReplaceAll(text, "%", "%%")
+}
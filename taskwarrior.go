@@ -0,0 +1,106 @@
+package pomo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// twTask is the part of `task export` pomo uses.
+type twTask struct {
+	UUID        string   `json:"uuid"`
+	Description string   `json:"description"`
+	Project     string   `json:"project"`
+	Tags        []string `json:"tags"`
+}
+
+func init() {
+	hooks = append(hooks, taskwarriorHook)
+}
+
+// taskwarriorHook starts and stops the taskwarrior task of the session and,
+// with the timewarrior config set, mirrors finished work sessions into
+// timewarrior. Missing binaries are skipped.
+func taskwarriorHook(event string, s Session) error {
+	if s.TW != "" && hasBinary("task") {
+		switch event {
+		case EventStart:
+			if err := execHook(fmt.Sprintf("task rc.verbose=nothing %s start", shellQuote(s.TW))); err != nil {
+				return err
+			}
+		case EventStop:
+			if err := stopTaskwarrior(s); err != nil {
+				return err
+			}
+		}
+	}
+
+	if event == EventStop && s.Type == WorkSession && conf.QueryBool("timewarrior") && hasBinary("timew") {
+		return timewTrack(s)
+	}
+	return nil
+}
+
+// stopTaskwarrior stops the taskwarrior task of the session. Besides the
+// stop event it runs when a running session is reset, as the session is
+// then deleted without being stopped.
+func stopTaskwarrior(s Session) error {
+	if s.TW == "" || !hasBinary("task") {
+		return nil
+	}
+	return execHook(fmt.Sprintf("task rc.verbose=nothing %s stop", shellQuote(s.TW)))
+}
+
+// useTaskwarrior fills the task, project and taskwarrior uuid of the
+// session from the task with the given id.
+func useTaskwarrior(s *Session, id string) error {
+	if !hasBinary("task") {
+		fmt.Fprintln(os.Stderr, "task not found, starting the session without taskwarrior")
+		return nil
+	}
+
+	out, err := ExecOutput(fmt.Sprintf("task rc.verbose=nothing rc.json.array=on %s export", shellQuote(id)))
+	if err != nil {
+		return err
+	}
+
+	var tasks []twTask
+	if err := json.Unmarshal([]byte(out), &tasks); err != nil {
+		return fmt.Errorf("failed to read task %s: %w", id, err)
+	}
+	if len(tasks) != 1 {
+		return fmt.Errorf("expected one task for %q, found %d", id, len(tasks))
+	}
+	if _, err := uuid.Parse(tasks[0].UUID); err != nil {
+		return fmt.Errorf("invalid uuid %q of task %s", tasks[0].UUID, id)
+	}
+
+	s.Task = tasks[0].Description
+	s.Project = tasks[0].Project
+	s.TW = tasks[0].UUID
+	return nil
+}
+
+// timewTrack records the session in timewarrior, tagged with its project
+// and task.
+func timewTrack(s Session) error {
+	var tags []string
+	for _, tag := range []string{s.Project, s.Task} {
+		if tag != "" {
+			tags = append(tags, shellQuote(tag))
+		}
+	}
+
+	const layout = "2006-01-02T15:04:05"
+	return execHook(fmt.Sprintf("timew track %s - %s %s :quiet",
+		s.StartTime.Format(layout), s.EndTime.Format(layout), strings.Join(tags, " ")))
+}
+
+func hasBinary(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
@@ -0,0 +1,69 @@
+package pomo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeTask puts a task binary on the PATH that records its arguments, and
+// returns the file they are recorded in.
+func fakeTask(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + shellQuote(calls) + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "task"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return calls
+}
+
+func TestStopTaskwarrior(t *testing.T) {
+	calls := fakeTask(t)
+	setupTest(t, time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), nil)
+
+	tw := uuid.NewString()
+	s := Session{ID: uuid.New(), StartTime: clock.Now(), Duration: 25 * time.Minute, Type: WorkSession, TW: tw}
+
+	if err := stopTaskwarrior(Session{}); err != nil {
+		t.Fatal(err)
+	}
+	if Exists(calls) {
+		t.Error("task ran for a session without a taskwarrior task")
+	}
+
+	if err := stopTaskwarrior(s); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := Read(calls)
+	if want := "rc.verbose=nothing " + tw + " stop"; strings.TrimSpace(got) != want {
+		t.Errorf("task called with %q, want %q", got, want)
+	}
+}
+
+func TestScanTaskwarriorUUID(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	line := func(tw string) string {
+		return "id=" + uuid.NewString() + " type=work start=" + start.Format(time.RFC3339) +
+			" end=0001-01-01T00:00:00Z duration=25m0s tw=" + tw + " | "
+	}
+
+	tw := uuid.NewString()
+	var s Session
+	if err := s.Scan(line(tw)); err != nil || s.TW != tw {
+		t.Errorf("Scan() = %v with tw %q, want %q", err, s.TW, tw)
+	}
+
+	for _, bad := range []string{"1;rm", "$(reboot)", "12"} {
+		var s Session
+		if err := s.Scan(line(bad)); err == nil {
+			t.Errorf("Scan() accepted tw=%s", bad)
+		}
+	}
+}
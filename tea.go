@@ -6,9 +6,20 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// tick generates a tick every second
+// tick generates a tick every second carrying the time of the package clock
 func tick() tea.Cmd {
-	return tea.Every(time.Second, func(t time.Time) tea.Msg {
-		return t
+	return tea.Every(time.Second, func(time.Time) tea.Msg {
+		return clock.Now()
 	})
 }
+
+// tickSoundFailed is sent when the ticking sound could not be played.
+type tickSoundFailed struct{ err error }
+
+// playTick plays the ticking sound, reporting a failure back to the UI.
+func playTick() tea.Msg {
+	if err := playSound(EventTick); err != nil {
+		return tickSoundFailed{err}
+	}
+	return nil
+}
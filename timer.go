@@ -0,0 +1,125 @@
+package pomo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StartTimer starts a named timer beside the pomodoro. A running timer with
+// the same name is restarted.
+func StartTimer(name string, dur time.Duration) error {
+	if name == "" || strings.ContainsAny(name, " |") {
+		return fmt.Errorf("timer names cannot be empty nor contain spaces or '|'")
+	}
+
+	var previous Session
+	if err := previous.get(name); err != nil {
+		return err
+	}
+	if previous.Name == name && previous.isRunning() {
+		if err := previous.Stop(); err != nil {
+			return err
+		}
+	}
+
+	timer := Session{Name: name}
+	return timer.Start(conf, dur, TimerSession)
+}
+
+// StopTimer stops the running timer with the given name.
+func StopTimer(name string) error {
+	var timer Session
+	if err := timer.GetTimer(name); err != nil {
+		return err
+	}
+	if !timer.isRunning() {
+		return fmt.Errorf("timer %q is not running", name)
+	}
+	return timer.Stop()
+}
+
+// ListTimers returns the running named timers, the closest to expire first.
+func ListTimers() ([]Session, error) {
+	sessions, err := ListSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	latest := map[string]Session{}
+	for _, s := range sessions {
+		if s.Name != "" {
+			latest[s.Name] = s
+		}
+	}
+
+	var timers []Session
+	for _, s := range latest {
+		if s.isRunning() {
+			timers = append(timers, s)
+		}
+	}
+	sort.Slice(timers, func(i, j int) bool {
+		return timers[i].Elapsed() < timers[j].Elapsed()
+	})
+
+	return timers, nil
+}
+
+// endExpiredTimers ends the timers that reached their planned end, at that
+// end, and returns the others. Watchers call it once the expiry alerts of
+// the timers were checked, so a timer alerts once and stops being listed.
+func endExpiredTimers(timers []Session, now time.Time) ([]Session, error) {
+	var running []Session
+	for _, t := range timers {
+		if t.Name == "" || now.Before(t.plannedEnd()) {
+			running = append(running, t)
+			continue
+		}
+		t.EndTime = t.plannedEnd()
+		if err := t.Save(); err != nil {
+			return timers, err
+		}
+		publish(EventStop, t)
+	}
+	return running, nil
+}
+
+// alerters keeps one alerter per session so every timer gets its own
+// alerts.
+type alerters map[string]*alerter
+
+// Check returns the alerts due for the pomodoro session and the timers.
+func (as alerters) Check(sessions []Session, now time.Time) []*alert {
+	var due []*alert
+	seen := map[string]bool{}
+	for _, s := range sessions {
+		key := s.Name
+		seen[key] = true
+		if as[key] == nil {
+			as[key] = &alerter{}
+		}
+		if a := as[key].Check(s, now); a != nil {
+			due = append(due, a)
+		}
+	}
+
+	// forget timers that stopped
+	for key := range as {
+		if !seen[key] {
+			delete(as, key)
+		}
+	}
+	return due
+}
+
+// watchedSessions returns the pomodoro session followed by the running
+// timers.
+func watchedSessions(session Session) []Session {
+	timers, err := ListTimers()
+	if err != nil {
+		return []Session{session}
+	}
+	return append([]Session{session}, timers...)
+}
@@ -0,0 +1,53 @@
+package pomo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestExpiredTimersEnd(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	fake := setupTest(t, start, nil)
+
+	tea := Session{ID: uuid.New(), Name: "tea", StartTime: start, Duration: 3 * time.Minute, Type: TimerSession}
+	laundry := Session{ID: uuid.New(), Name: "laundry", StartTime: start, Duration: time.Hour, Type: TimerSession}
+	writeSessions(t, tea, laundry)
+
+	as := alerters{}
+	check := func() []*alert {
+		timers, err := ListTimers()
+		if err != nil {
+			t.Fatal(err)
+		}
+		due := as.Check(timers, clock.Now())
+		if _, err := endExpiredTimers(timers, clock.Now()); err != nil {
+			t.Fatal(err)
+		}
+		return due
+	}
+
+	fake.Set(start.Add(3 * time.Minute))
+	if due := check(); len(due) != 1 || due[0].message != "Timer tea is done!" {
+		t.Fatalf("alerts at expiry = %+v, want the tea timer", due)
+	}
+
+	// ended at its planned end, no longer listed nor alerting
+	fake.Set(start.Add(10 * time.Minute))
+	if due := check(); len(due) != 0 {
+		t.Errorf("alerts after expiry = %d, want none", len(due))
+	}
+	timers, _ := ListTimers()
+	if len(timers) != 1 || timers[0].Name != "laundry" {
+		t.Errorf("timers = %+v, want only laundry", timers)
+	}
+
+	var ended Session
+	if err := ended.GetTimer("tea"); err != nil {
+		t.Fatal(err)
+	}
+	if want := start.Add(3 * time.Minute); !ended.EndTime.Equal(want) {
+		t.Errorf("tea ended at %v, want %v", ended.EndTime, want)
+	}
+}
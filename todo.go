@@ -0,0 +1,189 @@
+package pomo
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// todoItem is a line of a todo.txt file, see
+// https://github.com/todotxt/todo.txt for the format.
+type todoItem struct {
+	Line     int // index of the line in the file
+	Done     bool
+	Priority string // A to Z, empty when not prioritized
+	Text     string // the description without projects, contexts and tags
+	Projects []string
+	Contexts []string
+	Tags     map[string]string // key:value pairs such as due:2024-01-01
+}
+
+var (
+	todoPriority = regexp.MustCompile(`^\(([A-Z])\) `)
+	todoDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} `)
+	todoTag      = regexp.MustCompile(`^([^\s:]+):([^\s:/][^\s]*)$`)
+)
+
+func parseTodo(line string) todoItem {
+	item := todoItem{Tags: map[string]string{}}
+
+	if strings.HasPrefix(line, "x ") {
+		item.Done = true
+		line = line[2:]
+		// completion date
+		line = todoDate.ReplaceAllString(line, "")
+	}
+	if m := todoPriority.FindStringSubmatch(line); m != nil {
+		item.Priority = m[1]
+		line = line[len(m[0]):]
+	}
+	// creation date
+	line = todoDate.ReplaceAllString(line, "")
+
+	var words []string
+	for _, word := range strings.Fields(line) {
+		switch {
+		case len(word) > 1 && word[0] == '+':
+			item.Projects = append(item.Projects, word[1:])
+		case len(word) > 1 && word[0] == '@':
+			item.Contexts = append(item.Contexts, word[1:])
+		case todoTag.MatchString(word):
+			m := todoTag.FindStringSubmatch(word)
+			item.Tags[m[1]] = m[2]
+		default:
+			words = append(words, word)
+		}
+	}
+	item.Text = strings.Join(words, " ")
+
+	return item
+}
+
+// Project returns the first project of the item.
+func (t todoItem) Project() string {
+	if len(t.Projects) == 0 {
+		return ""
+	}
+	return t.Projects[0]
+}
+
+// String formats the item for the pickers.
+func (t todoItem) String() string {
+	var sb strings.Builder
+	if t.Priority != "" {
+		sb.WriteString("(" + t.Priority + ") ")
+	}
+	sb.WriteString(t.Text)
+	for _, p := range t.Projects {
+		sb.WriteString(" +" + p)
+	}
+	for _, c := range t.Contexts {
+		sb.WriteString(" @" + c)
+	}
+	if n, ok := t.Tags["pomo"]; ok {
+		sb.WriteString(" 🍅" + n)
+	}
+	return sb.String()
+}
+
+// pendingTodos returns the open items of the todo_file, highest priority
+// first.
+func pendingTodos() ([]todoItem, error) {
+	path := conf.QueryStringOr("todo_file", "")
+	if path == "" {
+		return nil, fmt.Errorf("todo_file is not set in the config")
+	}
+
+	lines, err := ReadLines(expandHome(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []todoItem
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		item := parseTodo(line)
+		item.Line = i
+		if !item.Done {
+			items = append(items, item)
+		}
+	}
+
+	// prioritized items first, in file order otherwise
+	sort.SliceStable(items, func(i, j int) bool {
+		pi, pj := items[i].Priority, items[j].Priority
+		if pi == "" || pj == "" {
+			return pi != "" && pj == ""
+		}
+		return pi < pj
+	})
+
+	return items, nil
+}
+
+// PickTodo lists the pending todos and asks which one to work on.
+func PickTodo() (todoItem, error) {
+	items, err := pendingTodos()
+	if err != nil {
+		return todoItem{}, err
+	}
+	if len(items) == 0 {
+		return todoItem{}, fmt.Errorf("no pending tasks in the todo file")
+	}
+
+	for i, item := range items {
+		fmt.Printf("%3d. %s\n", i+1, item)
+	}
+
+	answer := strings.TrimSpace(Input("Task: "))
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 1 || n > len(items) {
+		return todoItem{}, fmt.Errorf("invalid task %q", answer)
+	}
+	return items[n-1], nil
+}
+
+func init() {
+	hooks = append(hooks, todoHook)
+}
+
+// todoHook counts completed pomodoros into the pomo:N tag of the todo line
+// of the task when todo_count is set.
+func todoHook(event string, s Session) error {
+	if event != EventStop || !s.isCompleted() || s.Task == "" || !conf.QueryBool("todo_count") {
+		return nil
+	}
+
+	path := expandHome(conf.QueryStringOr("todo_file", ""))
+	if path == "" || !Exists(path) {
+		return nil
+	}
+
+	lines, err := ReadLines(path)
+	if err != nil {
+		return err
+	}
+
+	for i, line := range lines {
+		item := parseTodo(line)
+		if item.Done || item.Text != s.Task {
+			continue
+		}
+
+		count, _ := strconv.Atoi(item.Tags["pomo"])
+		tag := fmt.Sprintf("pomo:%d", count+1)
+		if _, ok := item.Tags["pomo"]; ok {
+			lines[i] = strings.Replace(line, "pomo:"+item.Tags["pomo"], tag, 1)
+		} else {
+			lines[i] = line + " " + tag
+		}
+
+		return Write(path, strings.Join(lines, "\n")+"\n")
+	}
+
+	return nil
+}
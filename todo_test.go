@@ -0,0 +1,86 @@
+package pomo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTodo(t *testing.T) {
+	tests := []struct {
+		line string
+		want todoItem
+	}{
+		{
+			line: "write the report",
+			want: todoItem{Text: "write the report"},
+		},
+		{
+			line: "(A) 2024-01-02 write the report +work @office due:2024-01-05 pomo:2",
+			want: todoItem{
+				Priority: "A",
+				Text:     "write the report",
+				Projects: []string{"work"},
+				Contexts: []string{"office"},
+				Tags:     map[string]string{"due": "2024-01-05", "pomo": "2"},
+			},
+		},
+		{
+			line: "x 2024-01-03 2024-01-02 write the report +work",
+			want: todoItem{Done: true, Text: "write the report", Projects: []string{"work"}},
+		},
+		{
+			line: "x (B) call the bank",
+			want: todoItem{Done: true, Priority: "B", Text: "call the bank"},
+		},
+		{
+			// not a priority nor a done mark in the middle of a line
+			line: "read x (C) https://example.com +a +b",
+			want: todoItem{Text: "read x (C) https://example.com", Projects: []string{"a", "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if tt.want.Tags == nil {
+				tt.want.Tags = map[string]string{}
+			}
+			if got := parseTodo(tt.line); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTodo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPendingTodos(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo.txt")
+	content := "fix the bike\n" +
+		"x 2024-01-01 (A) done already\n" +
+		"\n" +
+		"(B) write the report +work\n" +
+		"water the plants\n" +
+		"(A) call the bank\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	setupTest(t, time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), map[string]any{"todo_file": path})
+
+	items, err := pendingTodos()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Text)
+	}
+	want := []string{"call the bank", "write the report", "fix the bike", "water the plants"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pendingTodos() = %q, want %q", got, want)
+	}
+	if items[1].Line != 3 || items[1].Project() != "work" {
+		t.Errorf("write the report is on line %d of project %q, want 3 and work", items[1].Line, items[1].Project())
+	}
+}
@@ -7,15 +7,30 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/google/uuid"
 )
 
 type model struct {
-	prefix   string
-	quit     bool
-	notified bool
-	width    int
-	height   int
-	session  Session
+	prefix string
+	quit   bool
+	alerts alerter
+	timers []Session
+	// alerts of the named timers
+	timerAlerts alerters
+	width       int
+	height      int
+	session     Session
+	message     string
+	// current task of today's plan and its progress, e.g. "docs (2/4)"
+	planned string
+	// the ticking sound failed and is not played again
+	tickFailed bool
+
+	// todo.txt picker, the chosen task is used by the next work session
+	picking bool
+	todos   []todoItem
+	cursor  int
+	next    *todoItem
 }
 
 var (
@@ -53,7 +68,26 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.picking {
+			return m.updatePicker(msg)
+		}
+
 		switch msg.String() {
+		case "t": // Pick the task of the next work session
+			todos, err := pendingTodos()
+			if err != nil {
+				m.message = err.Error()
+				return m, nil
+			}
+			if len(todos) == 0 {
+				m.message = "No pending tasks in the todo file"
+				return m, nil
+			}
+			m.todos = todos
+			m.cursor = 0
+			m.picking = true
+			return m, nil
+
 		case "q", "ctrl+c":
 			// !! do not stop the session
 			// if m.session.isRunning() {
@@ -82,6 +116,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			var session Session
+			if m.next != nil {
+				session.Task = m.next.Text
+				session.Project = m.next.Project()
+				m.next = nil
+			} else if task, ok := autoPlannedTask(); ok {
+				session.Task = task
+			}
 			if err := session.Start(conf, dur, WorkSession); err != nil {
 				fmt.Printf("Failed to start work session: %v\n", err)
 			}
@@ -91,9 +132,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			m.session = session
-			m.notified = false
+			m.alerts.Reset()
+			if warning, err := calendarWarning(session); err != nil {
+				m.message = err.Error()
+			} else {
+				m.message = warning
+			}
 
-			return m, tick()
+			return m, nil
 
 		case "b": // Switch to break
 			duration := conf.QueryString("break")
@@ -122,47 +168,151 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			m.session = session
-			m.notified = false
+			m.alerts.Reset()
+			m.message = ""
 
-			return m, tick()
+			return m, nil
+
+		case "+", "=", "-": // Extend or shorten current timer
+			step, err := adjustStep("")
+			if err != nil {
+				m.message = err.Error()
+				return m, nil
+			}
+			if msg.String() == "-" {
+				step = -step
+			}
+			if err := m.session.Adjust(step); err != nil {
+				m.message = err.Error()
+				return m, nil
+			}
+			m.message = fmt.Sprintf("%s session is now %s long", m.session.Type, m.session.Duration)
+			return m, nil
+
+		case "s": // Snooze the expiry alerts
+			step, err := time.ParseDuration(conf.QueryStringOr("snooze", Snooze))
+			if err != nil {
+				m.message = err.Error()
+				return m, nil
+			}
+			if err := m.session.Snooze(step); err != nil {
+				m.message = err.Error()
+				return m, nil
+			}
+			m.message = fmt.Sprintf("Snoozed for %s", step)
+			return m, nil
 
 		case "r": // Reset current timer
 			if err := m.session.Reset(); err != nil {
 				fmt.Printf("Failed to stop session: %v\n", err)
 			}
-			m.notified = false
+			m.alerts.Reset()
 		}
-		return m, tick()
+		// the time.Time case keeps the one tick loop going, scheduling
+		// another tick here would start a second loop
+		return m, nil
 
 	case time.Time:
-		remaining := m.session.Elapsed()
-		if !m.notified && remaining <= 0 && m.session.isRunning() {
-			title := "Pomo Timer"
-			message := "Time to take a break!"
-			if m.session.Type == BreakSession {
-				message = "Break is over! Time to focus!"
-			}
+		// pick up changes made from the command line, e.g. `pomo snooze`
+		var session Session
+		if err := session.Get(); err == nil && session.ID != uuid.Nil {
+			m.session = session
+		}
 
-			go func() {
-				if err := sendNotification(title, message); err != nil {
-					fmt.Printf("Failed to send notification: %v\n", err)
-				}
-				if err := playAlert(); err != nil {
-					fmt.Printf("Failed to play alert: %v\n", err)
+		// the alerts are still checked while the daemon sends them, so none
+		// are sent twice nor all at once if it stops
+		daemon := daemonRunning()
+
+		if a := m.alerts.Check(m.session, msg); a != nil && !daemon {
+			a.send()
+		}
+
+		m.planned = plannedProgress(m.session)
+
+		if timers, err := ListTimers(); err == nil {
+			m.timers = timers
+		}
+		for _, a := range m.timerAlerts.Check(m.timers, msg) {
+			if !daemon {
+				a.send()
+			}
+		}
+		if !daemon {
+			if timers, err := endExpiredTimers(m.timers, msg); err == nil {
+				m.timers = timers
+			}
+			if msg.Unix()%focusEvery == 0 {
+				if err := m.session.trackFocus(); err != nil {
+					m.message = err.Error()
 				}
-			}()
-			m.notified = true
+			}
+		}
+
+		if conf.QueryBool("ticking") && !m.tickFailed && m.session.Type == WorkSession &&
+			m.session.isRunning() && m.session.Elapsed() > 0 {
+			return m, tea.Batch(tick(), playTick)
 		}
 		return m, tick()
+
+	case tickSoundFailed:
+		m.tickFailed = true
+		m.message = fmt.Sprintf("Ticking stopped: %v", msg.err)
+		return m, nil
 	}
 	return m, nil
 }
 
+func (m model) updatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "j", "down":
+		if m.cursor < len(m.todos)-1 {
+			m.cursor++
+		}
+	case "k", "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "enter":
+		next := m.todos[m.cursor]
+		m.next = &next
+		m.picking = false
+		m.message = "Next task: " + next.Text
+	case "esc", "q", "t":
+		m.picking = false
+	case "ctrl+c":
+		m.quit = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m model) viewPicker() string {
+	var sb strings.Builder
+	sb.WriteString(prefixStyle.Render("Pick the next task") + "\n\n")
+
+	for i, todo := range m.todos {
+		line := "  " + todo.String()
+		if i == m.cursor {
+			line = "> " + todo.String()
+			sb.WriteString(lipgloss.NewStyle().Bold(true).Render(line) + "\n")
+			continue
+		}
+		sb.WriteString(quitStyle.Render(line) + "\n")
+	}
+
+	sb.WriteString("\n" + quitStyle.Render("j/k: move • enter: select • esc: cancel") + "\n")
+	return containerStyle.Width(m.width).Render(sb.String())
+}
+
 func (m model) View() string {
 	if m.quit {
 		return quitStyle.Render("Goodbye!") + "\n"
 	}
 
+	if m.picking {
+		return m.viewPicker()
+	}
+
 	// Set colors based on session type and remaining time
 	var timeStyle = timerStyle
 	remaining := m.session.Elapsed()
@@ -208,8 +358,27 @@ func (m model) View() string {
 	timerText := timeStyle.Render(remainingStr)
 	sb.WriteString(timerText + "\n\n")
 
+	switch {
+	case m.planned != "":
+		sb.WriteString(quitStyle.Render(m.planned) + "\n\n")
+	case m.session.Task != "" && m.session.isRunning():
+		sb.WriteString(quitStyle.Render(m.session.Task) + "\n\n")
+	}
+
+	if m.message != "" {
+		sb.WriteString(quitStyle.Render(m.message) + "\n\n")
+	}
+
+	if len(m.timers) > 0 {
+		timers := make([]string, 0, len(m.timers))
+		for _, timer := range m.timers {
+			timers = append(timers, fmt.Sprintf("⏲ %s %s", timer.Name, StopWatchFormat(timer.Elapsed())))
+		}
+		sb.WriteString(prefixStyle.Render(strings.Join(timers, " • ")) + "\n\n")
+	}
+
 	helpStyle := quitStyle
-	sb.WriteString(helpStyle.Render("w: work • b: break • r: reset • q: quit") + "\n")
+	sb.WriteString(helpStyle.Render("w: work • b: break • t: task • +/-: extend/shorten • s: snooze • r: reset • q: quit") + "\n")
 
 	return containerStyle.Render(sb.String())
 }
@@ -226,8 +395,10 @@ func StartUI() error {
 	}
 
 	initialModel := model{
-		prefix:  prefix,
-		session: session,
+		prefix:      prefix,
+		session:     session,
+		timerAlerts: alerters{},
+		planned:     plannedProgress(session),
 	}
 
 	p := tea.NewProgram(
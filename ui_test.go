@@ -0,0 +1,27 @@
+package pomo
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+func TestKeysKeepOneTickLoop(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	for _, key := range []string{"w", "b", "r", "+", "s", "x"} {
+		t.Run(key, func(t *testing.T) {
+			setupTest(t, now, map[string]any{"duration": "25m", "break": "5m"})
+			session := Session{ID: uuid.New(), StartTime: now, Duration: 25 * time.Minute, Type: WorkSession}
+			writeSessions(t, session)
+
+			m := model{session: session}
+			_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+			if cmd != nil {
+				t.Errorf("Update(%q) returned a command, only the ticks schedule the next tick", key)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package pomo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// parseUntil returns the next time the wall clock reads value (15:04 or
+// 15:04:05) after now, which is tomorrow when it already passed today.
+func parseUntil(value string, now time.Time) (time.Time, error) {
+	var (
+		t   time.Time
+		err error
+	)
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err = time.Parse(layout, value); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error: the time must be like 13:55 or 13:55:30")
+	}
+
+	y, m, d := now.Date()
+	target := time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, now.Location())
+	if !target.After(now) {
+		target = time.Date(y, m, d+1, t.Hour(), t.Minute(), t.Second(), 0, now.Location())
+	}
+	return target, nil
+}
+
+// untilFlag parses the --until flag, the zero time when it is not set.
+func untilFlag(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return parseUntil(value, clock.Now())
+}
+
+// untilNextEvent returns the next event of the ics files, or of the
+// ics_files of the config when none are given, within a week.
+func untilNextEvent(files ...string) (icsEvent, error) {
+	if len(files) == 0 && len(conf.QueryStrings("ics_files")) == 0 {
+		return icsEvent{}, fmt.Errorf("no time given and ics_files is not set in the config")
+	}
+
+	now := clock.Now()
+	events, err := calendarEvents(now, now.AddDate(0, 0, 7), files...)
+	if err != nil {
+		return icsEvent{}, err
+	}
+
+	event, ok := nextEvent(events, now)
+	if !ok {
+		return icsEvent{}, fmt.Errorf("no event in the calendar within a week")
+	}
+	return event, nil
+}
+
+// Until stops the running session and starts a new one of mode that
+// ends at target.
+func Until(target time.Time, mode SessionType, task string) error {
+	var session Session
+	if err := session.Get(); err != nil {
+		return err
+	}
+	if session.ID != uuid.Nil && session.isRunning() {
+		if err := session.Stop(); err != nil {
+			return err
+		}
+	}
+
+	next := Session{Task: task}
+	if err := next.StartUntil(conf, target, mode); err != nil {
+		return err
+	}
+
+	prefix := WorkPrefix
+	if mode != WorkSession {
+		prefix = BreakPrefix
+	}
+	return conf.Set("prefix", prefix)
+}
@@ -0,0 +1,24 @@
+package pomo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUntilEmptyLog(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupTest(t, now, nil)
+	writeSessions(t)
+
+	if err := Until(now.Add(time.Hour), WorkSession, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := ListSessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].Duration != time.Hour {
+		t.Errorf("sessions = %+v, want a single session of an hour", sessions)
+	}
+}
@@ -0,0 +1,373 @@
+package pomo
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	_fs "io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rogpeppe/go-internal/lockedfile"
+)
+
+const (
+	// WebhookTimeout is the default time a webhook has to answer.
+	WebhookTimeout = "5s"
+	// WebhookMaxAttempts is how many times a delivery is tried before it is
+	// dropped from the outbox.
+	WebhookMaxAttempts = 10
+
+	outboxFilename = "outbox.json"
+)
+
+// webhookClient sends the deliveries, the timeout is set per request.
+var webhookClient = &http.Client{}
+
+// flushInBackground delivers the outbox without holding up the command that
+// queued it, by running "pomo webhooks flush" in a process of its own that
+// outlives it.
+var flushInBackground = func() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, "webhooks", "flush")
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// webhook is a configured endpoint, e.g.
+//
+//	"webhooks": [{
+//	    "url": "https://example.com/pomo",
+//	    "secret": "s3cret",
+//	    "events": ["session.start", "session.stop"],
+//	    "timeout": "5s"
+//	}]
+//
+// An empty events list receives every event.
+type webhook struct {
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret"`
+	Events  []string `json:"events"`
+	Timeout string   `json:"timeout"`
+}
+
+// webhookPayload is the JSON body posted to the webhooks.
+type webhookPayload struct {
+	Event   string           `json:"event"`
+	Time    time.Time        `json:"time"`
+	Session webhookSession   `json:"session"`
+	Totals  map[string]int64 `json:"totals"` // seconds per session type today
+}
+
+type webhookSession struct {
+	ID       string      `json:"id"`
+	Type     SessionType `json:"type"`
+	Task     string      `json:"task,omitempty"`
+	File     string      `json:"file"`
+	Start    time.Time   `json:"start"`
+	End      *time.Time  `json:"end,omitempty"`
+	Duration int64       `json:"duration"` // seconds
+	Planned  int64       `json:"planned"`  // seconds
+}
+
+// delivery is a pending webhook request kept in the outbox until the
+// endpoint accepts it.
+type delivery struct {
+	ID        string          `json:"id"`
+	URL       string          `json:"url"`
+	Event     string          `json:"event"`
+	Body      json.RawMessage `json:"body"`
+	Signature string          `json:"signature,omitempty"`
+	Timeout   string          `json:"timeout"`
+	Attempts  int             `json:"attempts"`
+	Next      time.Time       `json:"next"`
+}
+
+func init() {
+	hooks = append(hooks, webhookHook)
+}
+
+// webhookHook queues the event for every webhook interested in it. The
+// outbox is delivered in the background, the daemon retries what is left.
+func webhookHook(event string, s Session) error {
+	webhooks, err := configWebhooks()
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	body, err := json.Marshal(newWebhookPayload(event, s))
+	if err != nil {
+		return err
+	}
+
+	var queued []delivery
+	for _, w := range webhooks {
+		if !w.wants(event) {
+			continue
+		}
+		queued = append(queued, delivery{
+			ID:        uuid.NewString(),
+			URL:       w.URL,
+			Event:     event,
+			Body:      body,
+			Signature: sign(w.Secret, body),
+			Timeout:   w.Timeout,
+			Next:      clock.Now(),
+		})
+	}
+	if len(queued) == 0 {
+		return nil
+	}
+
+	err = updateOutbox(func(outbox []delivery) []delivery {
+		return append(outbox, queued...)
+	})
+	if err != nil {
+		return err
+	}
+
+	return flushInBackground()
+}
+
+// FlushWebhooks tries every delivery in the outbox that is due. Failed
+// deliveries are retried later with an exponential backoff and dropped
+// after WebhookMaxAttempts. It returns the number of deliveries left.
+//
+// Only one flush runs at a time. The outbox itself is only locked while it
+// is read and written back, so events can be queued during the requests.
+func FlushWebhooks() (int, error) {
+	// nothing was ever queued
+	if !Exists(outboxPath()) {
+		return 0, nil
+	}
+
+	unlock, err := lockedfile.MutexAt(outboxPath() + ".flush").Lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	var due []delivery
+	now := clock.Now()
+	err = updateOutbox(func(outbox []delivery) []delivery {
+		for _, d := range outbox {
+			if !now.Before(d.Next) {
+				due = append(due, d)
+			}
+		}
+		return outbox
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// the outcome of every due delivery, nil when it was delivered
+	results := map[string]error{}
+	var lastErr error
+	for _, d := range due {
+		results[d.ID] = d.deliver()
+		if results[d.ID] != nil {
+			lastErr = results[d.ID]
+		}
+	}
+
+	var pending int
+	err = updateOutbox(func(outbox []delivery) []delivery {
+		kept := outbox[:0]
+		for _, d := range outbox {
+			if err, ok := results[d.ID]; ok {
+				if err == nil {
+					continue
+				}
+				d.Attempts++
+				if d.Attempts >= WebhookMaxAttempts {
+					continue
+				}
+				d.Next = now.Add(backoff(d.Attempts))
+			}
+			kept = append(kept, d)
+		}
+		pending = len(kept)
+		return kept
+	})
+	if err != nil {
+		return pending, err
+	}
+	return pending, lastErr
+}
+
+func (d delivery) deliver() error {
+	timeout, err := time.ParseDuration(d.Timeout)
+	if err != nil || timeout <= 0 {
+		timeout, _ = time.ParseDuration(WebhookTimeout)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, d.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pomo")
+	req.Header.Set("X-Pomo-Event", d.Event)
+	req.Header.Set("X-Pomo-Delivery", d.ID)
+	if d.Signature != "" {
+		req.Header.Set("X-Pomo-Signature", d.Signature)
+	}
+
+	client := *webhookClient
+	client.Timeout = timeout
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", d.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s: unexpected status %s", d.URL, resp.Status)
+	}
+	return nil
+}
+
+func (w webhook) wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// sign returns the HMAC-SHA256 of body with secret, in the form of the
+// X-Pomo-Signature header.
+func sign(secret string, body []byte) string {
+	if secret == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff returns the wait before the next attempt, doubling from 30s up to
+// an hour.
+func backoff(attempts int) time.Duration {
+	wait := 30 * time.Second << (attempts - 1)
+	if wait <= 0 || wait > time.Hour {
+		wait = time.Hour
+	}
+	return wait
+}
+
+func newWebhookPayload(event string, s Session) webhookPayload {
+	payload := webhookPayload{
+		Event: event,
+		Time:  clock.Now(),
+		Session: webhookSession{
+			ID:       s.ID.String(),
+			Type:     s.Type,
+			Task:     s.Task,
+			File:     s.File,
+			Start:    s.StartTime,
+			Duration: int64(s.Duration.Seconds()),
+			Planned:  int64(s.Planned().Seconds()),
+		},
+		Totals: map[string]int64{},
+	}
+	if !s.EndTime.IsZero() {
+		end := s.EndTime
+		payload.Session.End = &end
+	}
+
+	if sessions, err := ListSessions(); err == nil {
+		from, to := dayBounds(clock.Now())
+		typeDurations, _ := summarizeSessions(filterSessions(sessions, from, to), from, to)
+		for t, d := range typeDurations {
+			payload.Totals[string(t)] = int64(d.Seconds())
+		}
+	}
+
+	return payload
+}
+
+func configWebhooks() ([]webhook, error) {
+	raw := conf.Query("webhooks")
+	if raw == nil {
+		return nil, nil
+	}
+
+	// round trip through JSON to decode the generic config value
+	buf, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var webhooks []webhook
+	if err := json.Unmarshal(buf, &webhooks); err != nil {
+		return nil, fmt.Errorf("invalid webhooks config: %w", err)
+	}
+	return webhooks, nil
+}
+
+func outboxPath() string {
+	return filepath.Join(conf.DirPath(), outboxFilename)
+}
+
+// updateOutbox replaces the outbox with what update returns, holding the
+// outbox lock from the read to the write.
+func updateOutbox(update func([]delivery) []delivery) error {
+	unlock, err := lockedfile.MutexAt(outboxPath() + ".lock").Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	outbox, err := readOutbox()
+	if err != nil {
+		return err
+	}
+	return writeOutbox(update(outbox))
+}
+
+func readOutbox() ([]delivery, error) {
+	if !Exists(outboxPath()) {
+		return nil, nil
+	}
+
+	buf, err := lockedfile.Read(outboxPath())
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(buf)) == 0 {
+		return nil, nil
+	}
+
+	var outbox []delivery
+	if err := json.Unmarshal(buf, &outbox); err != nil {
+		return nil, fmt.Errorf("invalid webhook outbox: %w", err)
+	}
+	return outbox, nil
+}
+
+func writeOutbox(outbox []delivery) error {
+	buf, err := json.Marshal(outbox)
+	if err != nil {
+		return err
+	}
+	return lockedfile.Write(outboxPath(), bytes.NewReader(buf), _fs.FileMode(DefaultPerms))
+}
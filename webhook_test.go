@@ -0,0 +1,190 @@
+package pomo
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// webhookServer records the requests it receives and fails them while fail
+// is set.
+type webhookServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	fail     bool
+}
+
+func newWebhookServer(t *testing.T) *webhookServer {
+	t.Helper()
+	ws := &webhookServer{}
+	ws.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		ws.requests = append(ws.requests, r)
+		ws.bodies = append(ws.bodies, body)
+		if ws.fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(ws.Close)
+	return ws
+}
+
+func (ws *webhookServer) received() int {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return len(ws.requests)
+}
+
+func setupWebhooks(t *testing.T, now time.Time, webhooks []webhook) *FakeClock {
+	t.Helper()
+	fake := setupTest(t, now, map[string]any{"webhooks": webhooks})
+	writeSessions(t)
+
+	prev := flushInBackground
+	flushInBackground = func() error { return nil }
+	t.Cleanup(func() { flushInBackground = prev })
+	return fake
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	ws := newWebhookServer(t)
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	setupWebhooks(t, start, []webhook{
+		{URL: ws.URL, Secret: "s3cret", Events: []string{EventStart}},
+	})
+
+	s := Session{ID: uuid.New(), StartTime: start, Duration: 25 * time.Minute, Type: WorkSession}
+	publish(EventStart, s)
+	publish(EventStop, s)
+
+	// only queued by the hooks
+	if got := ws.received(); got != 0 {
+		t.Fatalf("%d requests sent while publishing, want none", got)
+	}
+
+	pending, err := FlushWebhooks()
+	if err != nil || pending != 0 {
+		t.Fatalf("FlushWebhooks() = %d, %v, want 0 pending", pending, err)
+	}
+	if got := ws.received(); got != 1 {
+		t.Fatalf("received %d requests, want only the start event", got)
+	}
+
+	r, body := ws.requests[0], ws.bodies[0]
+	if got := r.Header.Get("X-Pomo-Event"); got != EventStart {
+		t.Errorf("X-Pomo-Event = %q, want %q", got, EventStart)
+	}
+	if got, want := r.Header.Get("X-Pomo-Signature"), sign("s3cret", body); got != want || want == "" {
+		t.Errorf("X-Pomo-Signature = %q, want %q", got, want)
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Session.ID != s.ID.String() || payload.Event != EventStart {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	ws := newWebhookServer(t)
+	ws.fail = true
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	fake := setupWebhooks(t, start, []webhook{{URL: ws.URL}})
+
+	publish(EventStart, Session{ID: uuid.New(), StartTime: start, Duration: 25 * time.Minute, Type: WorkSession})
+
+	if pending, err := FlushWebhooks(); err == nil || pending != 1 {
+		t.Fatalf("FlushWebhooks() = %d, %v, want 1 pending and an error", pending, err)
+	}
+
+	// kept in the outbox with the attempt and the backoff
+	outbox, err := readOutbox()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outbox) != 1 || outbox[0].Attempts != 1 || !outbox[0].Next.Equal(start.Add(30*time.Second)) {
+		t.Fatalf("outbox = %+v, want one delivery retried in 30s", outbox)
+	}
+
+	// not due yet
+	fake.Advance(10 * time.Second)
+	if _, err := FlushWebhooks(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ws.received(); got != 1 {
+		t.Errorf("received %d requests before the backoff, want 1", got)
+	}
+
+	ws.mu.Lock()
+	ws.fail = false
+	ws.mu.Unlock()
+
+	fake.Advance(20 * time.Second)
+	if pending, err := FlushWebhooks(); err != nil || pending != 0 {
+		t.Fatalf("FlushWebhooks() = %d, %v, want the delivery done", pending, err)
+	}
+	if got := ws.received(); got != 2 {
+		t.Errorf("received %d requests, want 2", got)
+	}
+	if outbox, _ := readOutbox(); len(outbox) != 0 {
+		t.Errorf("outbox = %+v, want empty", outbox)
+	}
+}
+
+func TestWebhookGivesUp(t *testing.T) {
+	ws := newWebhookServer(t)
+	ws.fail = true
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	fake := setupWebhooks(t, start, []webhook{{URL: ws.URL}})
+
+	publish(EventStart, Session{ID: uuid.New(), StartTime: start, Duration: 25 * time.Minute, Type: WorkSession})
+
+	for i := 0; i < WebhookMaxAttempts; i++ {
+		FlushWebhooks()
+		fake.Advance(time.Hour)
+	}
+	if got := ws.received(); got != WebhookMaxAttempts {
+		t.Errorf("received %d requests, want %d", got, WebhookMaxAttempts)
+	}
+	if outbox, _ := readOutbox(); len(outbox) != 0 {
+		t.Errorf("outbox = %+v, want the delivery dropped", outbox)
+	}
+}
+
+func TestPublishZeroSession(t *testing.T) {
+	ws := newWebhookServer(t)
+	setupWebhooks(t, time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), []webhook{{URL: ws.URL}})
+
+	publish(EventStop, Session{})
+
+	if outbox, _ := readOutbox(); len(outbox) != 0 {
+		t.Errorf("outbox = %+v, want nothing queued for the zero session", outbox)
+	}
+}
+
+func TestFlushWithoutOutbox(t *testing.T) {
+	setupWebhooks(t, time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), nil)
+
+	if pending, err := FlushWebhooks(); err != nil || pending != 0 {
+		t.Fatalf("FlushWebhooks() = %d, %v", pending, err)
+	}
+	for _, suffix := range []string{"", ".lock", ".flush"} {
+		if Exists(outboxPath() + suffix) {
+			t.Errorf("%s created without any delivery", outboxPath()+suffix)
+		}
+	}
+}